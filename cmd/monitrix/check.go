@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"monitrix/internal/config"
+	"monitrix/internal/monitor"
+)
+
+// runCheck probes every host once, prints the results and exits 0 when the
+// internet is up by UP_RULE or 1 when it isn't, for CI and cron. It writes
+// no files and starts no web server.
+func runCheck(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the results as a JSON array of ping results")
+	flags.Parse(args)
+
+	cfg, err := config.Load(os.Getenv("MONITRIX_CONFIG"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	for _, host := range cfg.Hosts {
+		if err := validateHost(host); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid host %q: %v\n", host, err)
+			os.Exit(2)
+		}
+	}
+	simulation, err := getSimulation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid simulation configuration: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Keep stdout to the results alone
+	mon, err := newMonitor(io.Discard, cfg.Hosts, cfg.Interval, cfg.Timeout, simulation)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid monitor configuration: %v\n", err)
+		os.Exit(2)
+	}
+	if *asJSON {
+		mon.Output = io.Discard
+	}
+
+	results := mon.PingAll()
+	online, failedHosts := monitor.IsInternetUp(results, mon.UpCriteria)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", err)
+			os.Exit(2)
+		}
+	} else if online {
+		degraded := monitor.DegradedHosts(results, mon.UpCriteria)
+		fmt.Printf("\nINTERNET: ONLINE - %d of %d hosts reachable\n", len(results)-len(failedHosts)-len(degraded), len(results))
+		if len(degraded) > 0 {
+			fmt.Printf("DEGRADED: %s refused connections\n", strings.Join(degraded, ", "))
+		}
+	}
+
+	if !online {
+		os.Exit(1)
+	}
+}
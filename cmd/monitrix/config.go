@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"monitrix/internal/config"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// templateVersion is the format version written to exported templates
+const templateVersion = 1
+
+// Template is a portable, non-secret monitoring configuration
+type Template struct {
+	Version  int               `json:"version"`
+	Settings map[string]string `json:"settings"`
+}
+
+// templateKeys are the settings describing a monitoring setup. Secrets
+// (API_TOKEN, MIRROR_TOKEN, PEER_TOKEN) and machine-specific settings such
+// as addresses, paths and peers are deliberately absent.
+var templateKeys = []string{
+	"MONITOR_HOSTS", "MONITOR_INTERVAL", "MONITOR_TIMEOUT", "HOST_INTERVALS", "PING_MODE", "EXPECT_BODY", "HOST_EXPECT_BODY",
+	"PROBE_NETWORK", "HOST_NETWORKS", "HOST_TIMEOUTS", "DNS_SERVER", "PROBE_PROXY",
+	"PROBE_PORTS", "HOST_PORTS", "PROBE_ALL_PORTS", "SOURCE_PORTS", "PROBE_DSCP", "HOST_DSCP",
+	"PROBES_PER_CHECK", "FAILURE_LOSS", "PROBE_RETRIES", "RETRY_BACKOFF_MS", "MAX_CONCURRENCY", "MAX_PLAUSIBLE_LATENCY_MS", "WARM_CONNECTIONS",
+	"INITIAL_PROBE", "ALIGN_PROBES", "RECORD_ADDRESSES",
+	"SLOW_PROBE_MS", "SLOW_PROBE_LOG_INTERVAL", "MISCONFIGURED_AFTER", "MISCONFIGURED_RETRY", "WARMUP_GRACE",
+	"PAUSE_SCHEDULE", "PAUSE_TIMEZONE",
+	"UP_RULE", "UP_MIN_HOSTS", "UP_THRESHOLD", "REFUSED_AS", "HOST_REFUSED_AS",
+	"EWMA_ALPHA", "LATENCY_CAP_MS", "SCORE_WEIGHTS",
+	"DOWNTIME_MERGE_GAP", "DOWNTIME_MAX_EVENTS", "MONITORING_GAP", "BRIDGE_GAPS",
+	"SEVERITY_MAJOR", "SEVERITY_CRITICAL", "SLA_OBJECTIVE", "HOST_SLA_OBJECTIVES", "REPORT_TITLE",
+	"STORAGE_BACKEND", "STORAGE_WRITE_MODE", "STORAGE_FLUSH_MS", "STORAGE_FLUSH_BATCH", "RETENTION_DAYS", "COMPRESS_LOGS", "AGGREGATE_CYCLES", "BATCH_WINDOW_MS", "TRANSFORMS", "LATENCY_BUCKETS", "LATENCY_BUCKETS_HOSTS",
+	"SIMULATE", "SIMULATE_SCENARIO",
+}
+
+// runConfig handles the "config export" and "config import <file>" subcommands
+func runConfig(args []string) {
+	switch {
+	case len(args) == 1 && args[0] == "export":
+		if err := exportConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export configuration: %v\n", err)
+			os.Exit(1)
+		}
+	case len(args) == 2 && args[0] == "import":
+		if err := importConfig(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to import configuration: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: monitrix config export > template.json")
+		fmt.Fprintln(os.Stderr, "       monitrix config import template.json > .env")
+		os.Exit(2)
+	}
+}
+
+// exportConfig writes the current non-secret configuration as a JSON template
+func exportConfig() error {
+	template := Template{Version: templateVersion, Settings: make(map[string]string)}
+	for _, key := range templateKeys {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		if key == "PROBE_PROXY" {
+			// Never export proxy credentials
+			proxy, err := url.Parse(value)
+			if err != nil {
+				return fmt.Errorf("PROBE_PROXY: %w", err)
+			}
+			proxy.User = nil
+			value = proxy.String()
+		}
+		template.Settings[key] = value
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(template)
+}
+
+// importConfig validates a template and prints it as .env lines
+func importConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var template Template
+	if err := json.Unmarshal(data, &template); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if template.Version != templateVersion {
+		return fmt.Errorf("unsupported template version %d", template.Version)
+	}
+
+	allowed := make(map[string]bool, len(templateKeys))
+	for _, key := range templateKeys {
+		allowed[key] = true
+	}
+	keys := make([]string, 0, len(template.Settings))
+	for key, value := range template.Settings {
+		if !allowed[key] {
+			return fmt.Errorf("setting %s is not allowed in templates", key)
+		}
+		if strings.ContainsAny(value, "\n\r") {
+			return fmt.Errorf("setting %s contains a line break", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Validate by running the settings through the same parsers as startup
+	for _, key := range templateKeys {
+		os.Unsetenv(key)
+	}
+	for key, value := range template.Settings {
+		os.Setenv(key, value)
+	}
+	if err := validateConfig(); err != nil {
+		return err
+	}
+
+	fmt.Printf("# Imported from %s\n", path)
+	for _, key := range keys {
+		fmt.Printf("%s=%s\n", key, template.Settings[key])
+	}
+	return nil
+}
+
+// validateConfig checks the environment-derived configuration without
+// starting anything
+func validateConfig() error {
+	// Only the environment is checked; a config file is not part of a template
+	cfg, err := config.Load("")
+	if err != nil {
+		return err
+	}
+	for _, host := range cfg.Hosts {
+		if err := validateHost(host); err != nil {
+			return fmt.Errorf("MONITOR_HOSTS: %q: %w", host, err)
+		}
+	}
+	if mode := monitor.PingMode(os.Getenv("PING_MODE")); mode != "" && !monitor.ValidPingMode(mode) {
+		return fmt.Errorf("PING_MODE: unknown mode %q, expected tcp, icmp or http", mode)
+	}
+	if mode := storage.WriteMode(os.Getenv("STORAGE_WRITE_MODE")); mode != "" && !storage.ValidWriteMode(mode) {
+		return fmt.Errorf("STORAGE_WRITE_MODE: unknown mode %q, expected held or reopen", mode)
+	}
+	if getEnvInt("STORAGE_FLUSH_MS", 0) > 0 && os.Getenv("STORAGE_WRITE_MODE") == string(storage.WriteReopen) {
+		return fmt.Errorf("STORAGE_FLUSH_MS: buffering can't be combined with STORAGE_WRITE_MODE=reopen")
+	}
+	if _, err := getHostTimeouts(); err != nil {
+		return err
+	}
+	if _, err := getHostIntervals(); err != nil {
+		return err
+	}
+	if _, err := getHostPorts(); err != nil {
+		return err
+	}
+	if _, _, err := getDSCP(); err != nil {
+		return err
+	}
+	if _, err := getSLAObjectives(); err != nil {
+		return err
+	}
+	if _, err := getProbePlugins(); err != nil {
+		return err
+	}
+	if _, _, err := getRefusedPolicies(); err != nil {
+		return err
+	}
+	if _, _, err := getNetworks(); err != nil {
+		return err
+	}
+	if _, err := getPauseSchedule(); err != nil {
+		return err
+	}
+	if _, err := getSimulation(); err != nil {
+		return err
+	}
+	if _, _, err := getLatencyBuckets(); err != nil {
+		return err
+	}
+	if _, err := getScoreWeights(); err != nil {
+		return fmt.Errorf("SCORE_WEIGHTS: %w", err)
+	}
+	if proxy := os.Getenv("PROBE_PROXY"); proxy != "" {
+		if _, err := monitor.ParseProxy(proxy); err != nil {
+			return fmt.Errorf("PROBE_PROXY: %w", err)
+		}
+	}
+	if sourcePorts := os.Getenv("SOURCE_PORTS"); sourcePorts != "" {
+		if _, err := monitor.ParsePortRange(sourcePorts); err != nil {
+			return fmt.Errorf("SOURCE_PORTS: %w", err)
+		}
+	}
+	if ports := os.Getenv("PROBE_PORTS"); ports != "" {
+		if _, err := monitor.ParsePorts(ports); err != nil {
+			return fmt.Errorf("PROBE_PORTS: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"monitrix/internal/config"
+)
+
+// cliFlags are the daemon's command-line options. Flags that aren't given
+// leave the environment and config file in charge.
+type cliFlags struct {
+	config   string
+	hosts    string
+	interval time.Duration
+	timeout  time.Duration
+	addr     string
+	dataDir  string
+
+	set map[string]bool // flags given on the command line
+}
+
+// parseFlags parses the daemon's arguments, printing usage and exiting for
+// -h and on errors
+func parseFlags(args []string) cliFlags {
+	var f cliFlags
+	flags := flag.NewFlagSet("monitrix", flag.ExitOnError)
+	flags.StringVar(&f.config, "config", os.Getenv("MONITRIX_CONFIG"), "YAML configuration `file` (MONITRIX_CONFIG)")
+	flags.StringVar(&f.hosts, "hosts", "", "comma-separated `hosts` to monitor (MONITOR_HOSTS)")
+	flags.DurationVar(&f.interval, "interval", 0, "check interval, e.g. 30s (MONITOR_INTERVAL)")
+	flags.DurationVar(&f.timeout, "timeout", 0, "probe timeout, e.g. 5s (MONITOR_TIMEOUT)")
+	flags.StringVar(&f.addr, "addr", "", "web server `address` (WEB_ADDR)")
+	flags.StringVar(&f.dataDir, "data-dir", "", "`directory` for logs and state (DATA_DIR)")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintln(out, "Usage: monitrix [flags]")
+		fmt.Fprintln(out, "       monitrix watch | check | config | report [args]")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Flags override the environment variables in parentheses, which override the config file.")
+		fmt.Fprintln(out)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+	f.set = make(map[string]bool)
+	flags.Visit(func(fl *flag.Flag) { f.set[fl.Name] = true })
+	return f
+}
+
+// apply overrides cfg with the flags that were given and revalidates it
+func (f cliFlags) apply(cfg *config.Config) error {
+	if f.set["hosts"] {
+		cfg.Hosts = config.SplitHosts(f.hosts)
+	}
+	if f.set["interval"] {
+		cfg.Interval = f.interval
+	}
+	if f.set["timeout"] {
+		cfg.Timeout = f.timeout
+	}
+	if f.set["addr"] {
+		cfg.WebAddr = f.addr
+	}
+	return cfg.Validate()
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -10,8 +12,12 @@ import (
 	"syscall"
 	"time"
 
+	"monitrix/internal/alert"
 	"monitrix/internal/api"
+	"monitrix/internal/config"
+	"monitrix/internal/metrics"
 	"monitrix/internal/monitor"
+	"monitrix/internal/pipeline"
 	"monitrix/internal/storage"
 )
 
@@ -23,112 +29,919 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// getHosts retrieves hosts from environment or returns defaults
-func getHosts() []string {
-	hostsEnv := os.Getenv("MONITOR_HOSTS")
-	if hostsEnv != "" {
-		hosts := strings.Split(hostsEnv, ",")
-		// Trim whitespace from each host
-		for i, host := range hosts {
-			hosts[i] = strings.TrimSpace(host)
+// getEnvInt retrieves a non-negative integer environment variable with fallback default
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			return n
 		}
-		return hosts
 	}
-	// Default hosts - using reliable, geographically distributed services
-	return []string{
-		"1.1.1.1",        // Cloudflare DNS (very reliable)
-		"8.8.8.8",        // Google DNS (very reliable)
-		"google.com",     // Google (Americas)
-		"cloudflare.com", // Cloudflare (Global CDN)
-		"github.com",     // GitHub (Tech infrastructure)
+	return defaultValue
+}
+
+// parseHostMap parses per-host overrides in the form "host=value,other=value"
+func parseHostMap(value string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	if value == "" {
+		return overrides, nil
+	}
+	for _, field := range strings.Split(value, ",") {
+		host, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok || host == "" {
+			return nil, fmt.Errorf("expected host=value, got %q", field)
+		}
+		overrides[host] = strings.TrimSpace(v)
 	}
+	return overrides, nil
 }
 
-// getPingInterval retrieves ping interval from environment or returns default
-func getPingInterval() time.Duration {
-	intervalEnv := os.Getenv("MONITOR_INTERVAL")
-	if intervalEnv != "" {
-		if seconds, err := strconv.Atoi(intervalEnv); err == nil && seconds > 0 {
-			return time.Duration(seconds) * time.Second
+// getNetworks retrieves the global and per-host probe networks from environment
+func getNetworks() (string, map[string]string, error) {
+	network := getEnv("PROBE_NETWORK", monitor.NetworkAuto)
+	if !monitor.ValidNetwork(network) {
+		return "", nil, fmt.Errorf("PROBE_NETWORK: unknown network %q", network)
+	}
+
+	hostNetworks, err := parseHostMap(os.Getenv("HOST_NETWORKS"))
+	if err != nil {
+		return "", nil, fmt.Errorf("HOST_NETWORKS: %w", err)
+	}
+	for host, n := range hostNetworks {
+		if !monitor.ValidNetwork(n) {
+			return "", nil, fmt.Errorf("HOST_NETWORKS: unknown network %q for %s", n, host)
 		}
 	}
-	// Default to 30 seconds
-	return 30 * time.Second
+	return network, hostNetworks, nil
 }
 
-func main() {
-	// Configuration with environment variable support
-	hosts := getHosts()
-	pingInterval := getPingInterval()
-	pingTimeout := 5 * time.Second
-	webAddr := getEnv("WEB_ADDR", "0.0.0.0:8080")
+// getHostTimeouts retrieves per-host probe timeouts (in milliseconds) from environment
+func getHostTimeouts() (map[string]time.Duration, error) {
+	overrides, err := parseHostMap(os.Getenv("HOST_TIMEOUTS"))
+	if err != nil {
+		return nil, fmt.Errorf("HOST_TIMEOUTS: %w", err)
+	}
+	timeouts := make(map[string]time.Duration, len(overrides))
+	for host, value := range overrides {
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("HOST_TIMEOUTS: invalid timeout %q for %s, expected milliseconds", value, host)
+		}
+		timeouts[host] = time.Duration(ms) * time.Millisecond
+	}
+	return timeouts, nil
+}
+
+// getHostIntervals retrieves per-host probe intervals (in seconds) from environment
+func getHostIntervals() (map[string]time.Duration, error) {
+	overrides, err := parseHostMap(os.Getenv("HOST_INTERVALS"))
+	if err != nil {
+		return nil, fmt.Errorf("HOST_INTERVALS: %w", err)
+	}
+	intervals := make(map[string]time.Duration, len(overrides))
+	for host, value := range overrides {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("HOST_INTERVALS: invalid interval %q for %s, expected seconds", value, host)
+		}
+		intervals[host] = time.Duration(seconds) * time.Second
+	}
+	return intervals, nil
+}
+
+// getHostChecks retrieves the hosts probed with several combined checks
+// from environment, in the form "api.example.com=tcp+http,edge=icmp|tcp"
+func getHostChecks() (map[string]monitor.HostChecks, error) {
+	specs, err := parseHostMap(os.Getenv("HOST_CHECKS"))
+	if err != nil {
+		return nil, fmt.Errorf("HOST_CHECKS: %w", err)
+	}
+	hostChecks := make(map[string]monitor.HostChecks, len(specs))
+	for host, spec := range specs {
+		checks, err := monitor.ParseHostChecks(spec)
+		if err != nil {
+			return nil, fmt.Errorf("HOST_CHECKS: %s: %w", host, err)
+		}
+		hostChecks[host] = checks
+	}
+	return hostChecks, nil
+}
+
+// getHostPorts retrieves per-host probe ports from environment, in the form
+// "host=22,5432;other=443"
+func getHostPorts() (map[string][]string, error) {
+	hostPorts := make(map[string][]string)
+	value := os.Getenv("HOST_PORTS")
+	if value == "" {
+		return hostPorts, nil
+	}
+	for _, spec := range strings.Split(value, ";") {
+		host, ports, ok := strings.Cut(strings.TrimSpace(spec), "=")
+		if !ok || host == "" {
+			return nil, fmt.Errorf("HOST_PORTS: expected host=ports, got %q", spec)
+		}
+		parsed, err := monitor.ParsePorts(ports)
+		if err != nil {
+			return nil, fmt.Errorf("HOST_PORTS: %s: %w", host, err)
+		}
+		hostPorts[host] = parsed
+	}
+	return hostPorts, nil
+}
+
+// getSLAObjectives retrieves report uptime targets from environment, e.g.
+// SLA_OBJECTIVE=99.5 and HOST_SLA_OBJECTIVES="1.1.1.1=99.99"
+func getSLAObjectives() (api.SLAObjectives, error) {
+	objectives := api.SLAObjectives{Default: api.DefaultSLAObjective}
+	parse := func(value string) (float64, bool) {
+		objective, err := strconv.ParseFloat(value, 64)
+		return objective, err == nil && objective > 0 && objective <= 100
+	}
+	if value := os.Getenv("SLA_OBJECTIVE"); value != "" {
+		var ok bool
+		if objectives.Default, ok = parse(value); !ok {
+			return objectives, fmt.Errorf("SLA_OBJECTIVE: invalid objective %q, expected a percentage", value)
+		}
+	}
+	overrides, err := parseHostMap(os.Getenv("HOST_SLA_OBJECTIVES"))
+	if err != nil {
+		return objectives, fmt.Errorf("HOST_SLA_OBJECTIVES: %w", err)
+	}
+	objectives.Hosts = make(map[string]float64, len(overrides))
+	for host, value := range overrides {
+		objective, ok := parse(value)
+		if !ok {
+			return objectives, fmt.Errorf("HOST_SLA_OBJECTIVES: invalid objective %q for %s, expected a percentage", value, host)
+		}
+		objectives.Hosts[host] = objective
+	}
+	return objectives, nil
+}
+
+// getProbePlugins retrieves the hosts probed by external plugins from
+// environment, e.g. "db.local=/opt/probes/pg --ssl"
+func getProbePlugins() (map[string]*monitor.ProbeExec, error) {
+	specs, err := parseHostMap(os.Getenv("PROBE_PLUGINS"))
+	if err != nil {
+		return nil, fmt.Errorf("PROBE_PLUGINS: %w", err)
+	}
+	plugins := make(map[string]*monitor.ProbeExec, len(specs))
+	for host, spec := range specs {
+		if plugins[host], err = monitor.ParseProbeExec(spec); err != nil {
+			return nil, fmt.Errorf("PROBE_PLUGINS: %s: %w", host, err)
+		}
+	}
+	return plugins, nil
+}
+
+// getDSCP retrieves the global and per-host probe DSCP marking from environment
+func getDSCP() (int, map[string]int, error) {
+	var dscp int
+	var err error
+	if value := os.Getenv("PROBE_DSCP"); value != "" {
+		if dscp, err = monitor.ParseDSCP(value); err != nil {
+			return 0, nil, fmt.Errorf("PROBE_DSCP: %w", err)
+		}
+	}
+
+	overrides, err := parseHostMap(os.Getenv("HOST_DSCP"))
+	if err != nil {
+		return 0, nil, fmt.Errorf("HOST_DSCP: %w", err)
+	}
+	hostDSCP := make(map[string]int, len(overrides))
+	for host, value := range overrides {
+		if hostDSCP[host], err = monitor.ParseDSCP(value); err != nil {
+			return 0, nil, fmt.Errorf("HOST_DSCP: %s: %w", host, err)
+		}
+	}
+	return dscp, hostDSCP, nil
+}
+
+// parseBuckets parses a comma-separated list of histogram bucket boundaries
+func parseBuckets(value string) ([]float64, error) {
+	var buckets []float64
+	for _, field := range strings.Split(value, ",") {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %w", field, err)
+		}
+		if len(buckets) > 0 && bound <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("buckets must be increasing, got %v after %v", bound, buckets[len(buckets)-1])
+		}
+		buckets = append(buckets, bound)
+	}
+	return buckets, nil
+}
+
+// getLatencyBuckets retrieves the default and per-host latency histogram buckets
+// Per-host overrides use the form "host=1,2,5;other=10,50,100"
+func getLatencyBuckets() ([]float64, map[string][]float64, error) {
+	var defaults []float64
+	if bucketsEnv := os.Getenv("LATENCY_BUCKETS"); bucketsEnv != "" {
+		buckets, err := parseBuckets(bucketsEnv)
+		if err != nil {
+			return nil, nil, fmt.Errorf("LATENCY_BUCKETS: %w", err)
+		}
+		defaults = buckets
+	}
+
+	hostBuckets := make(map[string][]float64)
+	if hostsEnv := os.Getenv("LATENCY_BUCKETS_HOSTS"); hostsEnv != "" {
+		for _, spec := range strings.Split(hostsEnv, ";") {
+			host, value, ok := strings.Cut(spec, "=")
+			if !ok {
+				return nil, nil, fmt.Errorf("LATENCY_BUCKETS_HOSTS: expected host=buckets, got %q", spec)
+			}
+			buckets, err := parseBuckets(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("LATENCY_BUCKETS_HOSTS: %s: %w", host, err)
+			}
+			hostBuckets[strings.TrimSpace(host)] = buckets
+		}
+	}
+	return defaults, hostBuckets, nil
+}
+
+// getPauseSchedule retrieves the optional probing pause schedule from environment
+func getPauseSchedule() (*monitor.Schedule, error) {
+	spec := os.Getenv("PAUSE_SCHEDULE")
+	if spec == "" {
+		return nil, nil
+	}
+	loc := time.Local
+	if tz := os.Getenv("PAUSE_TIMEZONE"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("PAUSE_TIMEZONE: %w", err)
+		}
+	}
+	schedule, err := monitor.ParseSchedule(spec, loc)
+	if err != nil {
+		return nil, fmt.Errorf("PAUSE_SCHEDULE: %w", err)
+	}
+	return schedule, nil
+}
+
+// getSimulation retrieves the optional simulation scenario from environment
+func getSimulation() (*monitor.Scenario, error) {
+	if getEnv("SIMULATE", "false") != "true" {
+		return nil, nil
+	}
+	scenario, err := monitor.ParseScenario(getEnv("SIMULATE_SCENARIO", monitor.DefaultScenario))
+	if err != nil {
+		return nil, fmt.Errorf("SIMULATE_SCENARIO: %w", err)
+	}
+	return scenario, nil
+}
+
+// getPeers retrieves the remote instances whose logs are merged into queries
+func getPeers() []storage.Reader {
+	var peers []storage.Reader
+	token := os.Getenv("PEER_TOKEN")
+	for _, peer := range strings.Split(os.Getenv("PEERS"), ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			peers = append(peers, storage.NewRemoteStorage(peer, token))
+		}
+	}
+	return peers
+}
+
+// validateHost checks a MONITOR_HOSTS entry, including DNS record targets
+// and internationalized names
+func validateHost(host string) error {
+	// Plugins decide for themselves what a host means
+	if plugins, _ := parseHostMap(os.Getenv("PROBE_PLUGINS")); plugins[host] != "" {
+		return nil
+	}
+	if _, ok, err := monitor.ParseHTTPTarget(host); ok {
+		return err
+	}
+	_, name, ok, err := monitor.ParseDNSTarget(host)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		name = host
+	}
+	_, err = monitor.ToASCII(name)
+	return err
+}
+
+// getCORSOrigins retrieves the browser origins allowed to call the API; nil
+// leaves the server default
+func getCORSOrigins() []string {
+	value, ok := os.LookupEnv("CORS_ORIGINS")
+	if !ok {
+		return nil
+	}
+	origins := []string{}
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	return origins
+}
+
+// getCompressMinBytes retrieves the response compression threshold; 0 disables compression
+func getCompressMinBytes() int {
+	if getEnv("COMPRESSION", "true") == "false" {
+		return 0
+	}
+	if minBytes := getEnvInt("COMPRESS_MIN_BYTES", 1024); minBytes > 0 {
+		return minBytes
+	}
+	return 1
+}
+
+// getScoreWeights retrieves quality score weights in the form "uptime:0.5,latency:0.3,loss:0.2"
+func getScoreWeights() (api.ScoreWeights, error) {
+	weights := api.DefaultScoreWeights
+	weightsEnv := os.Getenv("SCORE_WEIGHTS")
+	if weightsEnv == "" {
+		return weights, nil
+	}
+
+	for _, field := range strings.Split(weightsEnv, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), ":")
+		weight, err := strconv.ParseFloat(value, 64)
+		if !ok || err != nil || weight < 0 {
+			return weights, fmt.Errorf("invalid weight %q, expected name:value", field)
+		}
+		switch name {
+		case "uptime":
+			weights.Uptime = weight
+		case "latency":
+			weights.Latency = weight
+		case "loss":
+			weights.Loss = weight
+		default:
+			return weights, fmt.Errorf("unknown weight %q, expected uptime, latency or loss", name)
+		}
+	}
+	return weights, nil
+}
+
+// getUpCriteria retrieves the internet-up rule from environment or returns defaults
+func getUpCriteria() monitor.UpCriteria {
+	criteria := monitor.UpCriteria{
+		Rule:      monitor.UpRuleHosts,
+		MinHosts:  getEnvInt("UP_MIN_HOSTS", 1),
+		Threshold: 50,
+	}
+	switch rule := monitor.UpRule(os.Getenv("UP_RULE")); rule {
+	case monitor.UpRuleAll, monitor.UpRuleProbes:
+		criteria.Rule = rule
+	}
+	if thresholdEnv := os.Getenv("UP_THRESHOLD"); thresholdEnv != "" {
+		if threshold, err := strconv.ParseFloat(thresholdEnv, 64); err == nil && threshold >= 0 && threshold < 100 {
+			criteria.Threshold = threshold
+		}
+	}
+	if refused, hostRefused, err := getRefusedPolicies(); err == nil {
+		criteria.Refused, criteria.HostRefused = refused, hostRefused
+	} else {
+		fmt.Fprintf(os.Stderr, "Ignoring refused connection policy: %v\n", err)
+	}
+	return criteria
+}
+
+// getRefusedPolicies retrieves how refused connections count, globally and
+// per host, from environment
+func getRefusedPolicies() (monitor.RefusedPolicy, map[string]monitor.RefusedPolicy, error) {
+	refused := monitor.RefusedPolicy(getEnv("REFUSED_AS", string(monitor.RefusedDown)))
+	if !monitor.ValidRefusedPolicy(refused) {
+		return "", nil, fmt.Errorf("REFUSED_AS: unknown policy %q, expected down, up or degraded", refused)
+	}
+
+	overrides, err := parseHostMap(os.Getenv("HOST_REFUSED_AS"))
+	if err != nil {
+		return "", nil, fmt.Errorf("HOST_REFUSED_AS: %w", err)
+	}
+	hostRefused := make(map[string]monitor.RefusedPolicy, len(overrides))
+	for host, value := range overrides {
+		policy := monitor.RefusedPolicy(value)
+		if !monitor.ValidRefusedPolicy(policy) {
+			return "", nil, fmt.Errorf("HOST_REFUSED_AS: unknown policy %q for %s", value, host)
+		}
+		hostRefused[host] = policy
+	}
+	return refused, hostRefused, nil
+}
+
+// getStatsOptions retrieves statistics settings from environment or returns defaults
+func getStatsOptions(up monitor.UpCriteria) api.StatsOptions {
+	opts := api.StatsOptions{
+		Up:        up,
+		EWMAAlpha: api.DefaultEWMAAlpha,
+		MergeGap:  time.Duration(getEnvInt("DOWNTIME_MERGE_GAP", 0)) * time.Second,
+
+		GapThreshold: time.Duration(getEnvInt("MONITORING_GAP", 0)) * time.Second,
+		BridgeGaps:   getEnv("BRIDGE_GAPS", "true") == "true",
 
-	// Get absolute paths
+		LatencyCap: time.Duration(getEnvInt("LATENCY_CAP_MS", 0)) * time.Millisecond,
+
+		MaxDowntimeEvents: getEnvInt("DOWNTIME_MAX_EVENTS", 0),
+		DowntimeSampling:  api.SampleLongest,
+
+		Severity: api.SeverityThresholds{
+			Major:    time.Duration(getEnvInt("SEVERITY_MAJOR", 60)) * time.Second,
+			Critical: time.Duration(getEnvInt("SEVERITY_CRITICAL", 900)) * time.Second,
+		},
+	}
+	if alphaEnv := os.Getenv("EWMA_ALPHA"); alphaEnv != "" {
+		if alpha, err := strconv.ParseFloat(alphaEnv, 64); err == nil && alpha > 0 && alpha <= 1 {
+			opts.EWMAAlpha = alpha
+		}
+	}
+	if weights, err := getScoreWeights(); err == nil {
+		opts.ScoreWeights = weights
+	} else {
+		fmt.Fprintf(os.Stderr, "Ignoring SCORE_WEIGHTS: %v\n", err)
+		opts.ScoreWeights = api.DefaultScoreWeights
+	}
+	return opts
+}
+
+// newMonitor creates a monitor configured from environment, describing
+// notable settings on notes
+func newMonitor(notes io.Writer, hosts []string, interval, timeout time.Duration, simulation *monitor.Scenario) (*monitor.Monitor, error) {
+	var err error
+	mon := monitor.NewMonitor(hosts, interval, timeout)
+	mon.Simulation = simulation
+	mon.UpCriteria = getUpCriteria()
+	if concurrency := getEnvInt("MAX_CONCURRENCY", 0); concurrency > 0 {
+		mon.MaxConcurrency = concurrency
+		fmt.Fprintf(notes, "Probe concurrency: %d (MAX_CONCURRENCY)\n", concurrency)
+	} else {
+		var source string
+		mon.MaxConcurrency, source = monitor.DefaultConcurrency()
+		fmt.Fprintf(notes, "Probe concurrency: %d (%s)\n", mon.MaxConcurrency, source)
+	}
+	if mode := monitor.PingMode(os.Getenv("PING_MODE")); mode != "" {
+		if !monitor.ValidPingMode(mode) {
+			return nil, fmt.Errorf("PING_MODE: unknown mode %q, expected tcp, icmp or http", mode)
+		}
+		mon.PingMode = mode
+	}
+	if mon.HostChecks, err = getHostChecks(); err != nil {
+		return nil, err
+	}
+	mon.ExpectBody = os.Getenv("EXPECT_BODY")
+	if mon.HostExpectBody, err = parseHostMap(os.Getenv("HOST_EXPECT_BODY")); err != nil {
+		return nil, fmt.Errorf("HOST_EXPECT_BODY: %w", err)
+	}
+	mon.DNSServer = os.Getenv("DNS_SERVER")
+	mon.RecordAddresses = getEnv("RECORD_ADDRESSES", "false") == "true"
+	if proxyURL := os.Getenv("PROBE_PROXY"); proxyURL != "" {
+		if mon.Proxy, err = monitor.ParseProxy(proxyURL); err != nil {
+			return nil, fmt.Errorf("PROBE_PROXY: %w", err)
+		}
+		fmt.Fprintf(notes, "Probing through proxy: %s\n", mon.Proxy.Redacted())
+	}
+	if ports := os.Getenv("PROBE_PORTS"); ports != "" {
+		if mon.Ports, err = monitor.ParsePorts(ports); err != nil {
+			return nil, fmt.Errorf("PROBE_PORTS: %w", err)
+		}
+	}
+	mon.ProbeAllPorts = getEnv("PROBE_ALL_PORTS", "false") == "true"
+	if sourcePorts := os.Getenv("SOURCE_PORTS"); sourcePorts != "" {
+		if mon.SourcePorts, err = monitor.ParsePortRange(sourcePorts); err != nil {
+			return nil, fmt.Errorf("SOURCE_PORTS: %w", err)
+		}
+	}
+	mon.MaxPlausibleLatency = time.Duration(getEnvInt("MAX_PLAUSIBLE_LATENCY_MS", 0)) * time.Millisecond
+	mon.WarmConnections = getEnv("WARM_CONNECTIONS", "false") == "true"
+	mon.SkipInitialProbe = getEnv("INITIAL_PROBE", "true") == "false"
+	mon.AlignToInterval = getEnv("ALIGN_PROBES", "false") == "true"
+	mon.CrashOnPanic = getEnv("PANIC_RECOVERY", "true") == "false"
+	mon.SlowProbeThreshold = time.Duration(getEnvInt("SLOW_PROBE_MS", 0)) * time.Millisecond
+	mon.SlowProbeInterval = time.Duration(getEnvInt("SLOW_PROBE_LOG_INTERVAL", 300)) * time.Second
+	mon.MisconfiguredAfter = getEnvInt("MISCONFIGURED_AFTER", 0)
+	mon.MisconfiguredRetry = getEnvInt("MISCONFIGURED_RETRY", 60)
+	mon.WarmupGrace = time.Duration(getEnvInt("WARMUP_GRACE", 0)) * time.Second
+	mon.ProbesPerCheck = getEnvInt("PROBES_PER_CHECK", 0)
+	if lossEnv := os.Getenv("FAILURE_LOSS"); lossEnv != "" {
+		loss, err := strconv.ParseFloat(lossEnv, 64)
+		if err != nil || loss <= 0 || loss > 100 {
+			return nil, fmt.Errorf("FAILURE_LOSS: invalid percentage %q", lossEnv)
+		}
+		mon.FailureLoss = loss
+	}
+	mon.Retries = getEnvInt("PROBE_RETRIES", 0)
+	mon.RetryBackoff = time.Duration(getEnvInt("RETRY_BACKOFF_MS", 0)) * time.Millisecond
+	if mon.Network, mon.HostNetworks, err = getNetworks(); err != nil {
+		return nil, err
+	}
+	if mon.HostTimeouts, err = getHostTimeouts(); err != nil {
+		return nil, err
+	}
+	if mon.HostIntervals, err = getHostIntervals(); err != nil {
+		return nil, err
+	}
+	if mon.HostPorts, err = getHostPorts(); err != nil {
+		return nil, err
+	}
+	for host, interval := range mon.HostIntervals {
+		fmt.Fprintf(notes, "Checking %s every %v\n", host, interval)
+	}
+	if mon.DSCP, mon.HostDSCP, err = getDSCP(); err != nil {
+		return nil, err
+	}
+	if mon.Plugins, err = getProbePlugins(); err != nil {
+		return nil, err
+	}
+	for host, plugin := range mon.Plugins {
+		fmt.Fprintf(notes, "Probing %s with plugin: %s\n", host, plugin)
+	}
+	if mon.PauseSchedule, err = getPauseSchedule(); err != nil {
+		return nil, err
+	}
+	return mon, nil
+}
+
+// shutdownTimeout bounds how long in-flight API requests get to finish on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// logStorage is a storage backend that can also take the aggregator's roll-up entries
+type logStorage interface {
+	storage.Storage
+	storage.EntrySink
+}
+
+// openStorage opens the storage backend in dataDir
+func openStorage(dataDir, backend string) (logStorage, error) {
+	switch backend {
+	case config.BackendFile:
+		mode := storage.WriteMode(getEnv("STORAGE_WRITE_MODE", string(storage.WriteHeldOpen)))
+		if flush := getEnvInt("STORAGE_FLUSH_MS", 0); flush > 0 {
+			if mode == storage.WriteReopen {
+				return nil, fmt.Errorf("STORAGE_FLUSH_MS can't be combined with STORAGE_WRITE_MODE=reopen")
+			}
+			return storage.NewBufferedFileStorage(dataDir, time.Duration(flush)*time.Millisecond, getEnvInt("STORAGE_FLUSH_BATCH", storage.DefaultMaxBatch))
+		}
+		return storage.NewFileStorage(dataDir, mode)
+	case config.BackendSQLite:
+		return storage.NewSQLiteStorage(filepath.Join(dataDir, sqliteFile))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q, expected file or sqlite", backend)
+	}
+}
+
+// maintainLogs now and then once a day deletes log files older than days,
+// when positive, after summarizing each by criteria, and gzips past days'
+// files when compress is set
+func maintainLogs(dataDir string, days int, compress bool, criteria monitor.UpCriteria, interval time.Duration) {
+	maxAge := time.Duration(days) * 24 * time.Hour
+	for {
+		if days > 0 {
+			// A day's raw log is only pruned once its summary is stored
+			summarized, err := storage.SummarizeOlderThan(dataDir, maxAge, criteria, interval)
+			if summarized > 0 {
+				fmt.Printf("Summarized %d day(s) of logs before pruning\n", summarized)
+			}
+			var removed int
+			if err != nil {
+				err = fmt.Errorf("failed to summarize them first: %w", err)
+			} else {
+				removed, err = storage.PruneOlderThan(dataDir, maxAge)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to prune old logs: %v\n", err)
+			} else if removed > 0 {
+				fmt.Printf("Pruned %d log file(s) older than %d days\n", removed, days)
+			}
+		}
+		if compress {
+			compressed, err := storage.CompressClosed(dataDir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to compress old logs: %v\n", err)
+			} else if compressed > 0 {
+				fmt.Printf("Compressed %d log file(s)\n", compressed)
+			}
+		}
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+// sqliteFile is the database file the sqlite backend keeps in the data directory
+const sqliteFile = "monitrix.db"
+
+// defaultDirs returns the data and web directories: next to the source tree
+// of the executable, or under the working directory during development
+func defaultDirs() (dataDir, webDir string, err error) {
 	execPath, err := os.Executable()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to get executable path: %v\n", err)
-		os.Exit(1)
+		return "", "", err
 	}
 	baseDir := filepath.Dir(execPath)
-	dataDir := filepath.Join(baseDir, "..", "..", "data")
-	webDir := filepath.Join(baseDir, "..", "..", "web")
+	dataDir = filepath.Join(baseDir, "..", "..", "data")
+	webDir = filepath.Join(baseDir, "..", "..", "web")
 
 	// For development, use current directory
 	if wd, err := os.Getwd(); err == nil {
 		dataDir = filepath.Join(wd, "data")
 		webDir = filepath.Join(wd, "web")
 	}
+	return dataDir, webDir, nil
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "watch":
+			runWatch()
+			return
+		case "check":
+			runCheck(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		case "report":
+			runReport(os.Args[2:])
+			return
+		}
+	}
+
+	// Configuration from an optional YAML file, overridden by the
+	// environment and then by command-line flags
+	flags := parseFlags(os.Args[1:])
+	cfg, err := config.Load(flags.config)
+	if err == nil {
+		err = flags.apply(&cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	for _, host := range cfg.Hosts {
+		if err := validateHost(host); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid host %q: %v\n", host, err)
+			os.Exit(1)
+		}
+	}
+
+	dataDir, webDir, err := defaultDirs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get executable path: %v\n", err)
+		os.Exit(1)
+	}
+	webDir = getEnv("WEB_DIR", webDir)
+	dataDir = getEnv("DATA_DIR", dataDir)
+	if flags.dataDir != "" {
+		dataDir = flags.dataDir
+	}
+
+	simulation, err := getSimulation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid simulation configuration: %v\n", err)
+		os.Exit(1)
+	}
+	// Keep synthetic results away from real data
+	if simulation != nil {
+		dataDir = filepath.Join(dataDir, "simulated")
+	}
 
 	fmt.Printf("Monitrix - Network Monitoring Tool\n")
 	fmt.Printf("===================================\n")
-	fmt.Printf("Monitoring hosts: %v\n", hosts)
-	fmt.Printf("Check interval: %v\n", pingInterval)
+	if flags.config != "" {
+		fmt.Printf("Config file: %s\n", flags.config)
+	}
+	fmt.Printf("Monitoring hosts: %v\n", cfg.Hosts)
+	fmt.Printf("Check interval: %v\n", cfg.Interval)
 	fmt.Printf("Data directory: %s\n", dataDir)
 	fmt.Printf("Web directory: %s\n", webDir)
+	if simulation != nil {
+		fmt.Printf("SIMULATION MODE: results are synthetic (%s)\n", getEnv("SIMULATE_SCENARIO", monitor.DefaultScenario))
+	}
 	fmt.Printf("\n")
 
 	// Initialize storage
-	fileStorage, err := storage.NewFileStorage(dataDir)
+	store, err := openStorage(dataDir, cfg.Storage.Backend)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize storage: %v\n", err)
 		os.Exit(1)
 	}
-	defer fileStorage.Close()
+
+	// Exit non-zero once the deferred cleanup below has run
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Initialize optional mirror of the result stream
+	var mirror storage.Sink
+	if target := os.Getenv("MIRROR_TARGET"); target != "" {
+		mirror, err = storage.NewMirror(target, os.Getenv("MIRROR_TOKEN"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize mirror: %v\n", err)
+			os.Exit(1)
+		}
+		defer mirror.Close()
+		fmt.Printf("Mirroring results to: %s\n", target)
+	}
+
+	// Initialize Prometheus metrics
+	defaultBuckets, hostBuckets, err := getLatencyBuckets()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid metrics configuration: %v\n", err)
+		os.Exit(1)
+	}
+	promMetrics := metrics.New(defaultBuckets, hostBuckets)
 
 	// Initialize monitor
-	mon := monitor.NewMonitor(hosts, pingInterval, pingTimeout)
+	mon, err := newMonitor(os.Stdout, cfg.Hosts, cfg.Interval, cfg.Timeout, simulation)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid monitor configuration: %v\n", err)
+		os.Exit(1)
+	}
+	promMetrics.CountPanics(mon.Panics)
+
+	// Optionally delete logs past the retention period, keeping a daily
+	// summary of each, and compress past days
+	days, compress := cfg.Storage.RetentionDays, getEnv("COMPRESS_LOGS", "false") == "true"
+	if days > 0 || compress {
+		if cfg.Storage.Backend == config.BackendFile {
+			if days > 0 {
+				fmt.Printf("Keeping logs for %d days, then daily summaries\n", days)
+			}
+			go maintainLogs(dataDir, days, compress, mon.UpCriteria, mon.LongestInterval())
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: RETENTION_DAYS and COMPRESS_LOGS only apply to file storage\n")
+		}
+	}
+
+	// Optionally trace probe cycles to an OTLP collector
+	tracer, shutdownTracing, err := newTracer()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize tracing: %v\n", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing()
+	if tracer != nil {
+		mon.Tracer = tracer
+		fmt.Printf("Tracing probe cycles over OTLP\n")
+	}
+
+	// Optionally roll cycles up into summary records before they're stored
+	var sink storage.Sink = store
+	window := getEnvInt("AGGREGATE_CYCLES", 0)
+	if window > 1 {
+		sink = storage.NewAggregator(store, window, mon.UpCriteria, mon.LongestInterval())
+		fmt.Printf("Aggregating every %d cycles into one record\n", window)
+	}
+	defer sink.Close()
+
+	// Post-process results before they are observed and stored
+	transforms, err := pipeline.Parse(os.Getenv("TRANSFORMS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid TRANSFORMS: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Restore the last known state so the API has something to serve
+	// before the first cycle completes
+	statePath := getEnv("STATE_FILE", filepath.Join(dataDir, "state.json"))
+	if snapshot, err := monitor.LoadSnapshot(statePath); err == nil {
+		mon.Restore(snapshot)
+		fmt.Printf("Restored last known state from %s\n", statePath)
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Ignoring state snapshot: %v\n", err)
+	}
 
 	// Create channels for communication
 	resultChan := make(chan []monitor.PingResult, 10)
 	stopChan := make(chan struct{})
 
 	// Start monitoring in background
-	go mon.Start(resultChan, stopChan)
+	monitorDone := make(chan struct{})
+	go func() {
+		defer close(monitorDone)
+		mon.Start(resultChan, stopChan)
+	}()
+
+	// Optionally merge results delivered within a short window into one write
+	batches := pipeline.Batch(resultChan, time.Duration(getEnvInt("BATCH_WINDOW_MS", 0))*time.Millisecond)
+
+	// Set up the web server before the writer, which feeds it live results
+	peers := getPeers()
+	if len(peers) > 0 {
+		fmt.Printf("Federating logs from %d peer(s)\n", len(peers))
+	}
+	// Optionally notify external services of outages
+	var notifiers []alert.Notifier
+	if url := cfg.Notifiers.WebhookURL; url != "" {
+		notifiers = append(notifiers, promMetrics.InstrumentNotifier("webhook", alert.NewWebhookNotifier(url)))
+		fmt.Printf("Sending outage alerts to: %s\n", url)
+	}
+	if url := cfg.Notifiers.SlackWebhookURL; url != "" {
+		notifiers = append(notifiers, promMetrics.InstrumentNotifier("slack", alert.NewSlackNotifier(url)))
+		fmt.Printf("Sending outage alerts to Slack\n")
+	}
+	var alerts alert.Notifier
+	var alertQueue *alert.Queue
+	if len(notifiers) > 0 {
+		alertQueue = alert.NewQueue(notifiers...)
+		defer alertQueue.Close()
+		alerts = alertQueue
+	}
+
+	// Stats call monitoring stalled after two stored entries' worth of
+	// silence; an aggregated entry covers a whole window of cycles
+	statsOpts := getStatsOptions(mon.UpCriteria)
+	statsOpts.StaleAfter = 2 * mon.ShortestInterval() * time.Duration(max(window, 1))
+	statsOpts.ProbeInterval = mon.LongestInterval()
+
+	server := api.NewServer(store, webDir, api.Options{
+		AuthToken: os.Getenv("API_TOKEN"),
+		Stats:     statsOpts,
+		Ingest:    store,
+		Metrics:   promMetrics.Handler(),
+		Live:      mon.Snapshot,
+		Peers:     peers,
+		Alerts:    alerts,
+
+		CompressMinBytes: getCompressMinBytes(),
+		BindRetry:        time.Duration(getEnvInt("BIND_RETRY", 30)) * time.Second,
+		CORSOrigins:      getCORSOrigins(),
+		Interval:         mon.ShortestInterval(),
+	})
+	promMetrics.CountDowntimes(server.DowntimeEvents)
+
+	// Carry the check and outage counters on from the stored logs
+	if logs, err := store.ReadLogs(nil, nil); err == nil {
+		promMetrics.Seed(logs)
+		server.SeedDowntimes(logs)
+	} else {
+		fmt.Fprintf(os.Stderr, "Failed to read logs for metrics: %v\n", err)
+	}
+	server.RegisterDebugState("monitor", func() any { return mon.Snapshot() })
+	server.RegisterDebugState("result_queue", func() any {
+		return map[string]int{"depth": len(resultChan), "capacity": cap(resultChan)}
+	})
+	if alertQueue != nil {
+		server.RegisterDebugState("alert_queue", func() any {
+			depth, capacity := alertQueue.Depth()
+			return map[string]int{"depth": depth, "capacity": capacity}
+		})
+	}
+	if fileStore, ok := store.(*storage.FileStorage); ok {
+		server.RegisterDebugState("storage_buffer", func() any {
+			entries, size := fileStore.BufferDepth()
+			return map[string]int{"entries": entries, "bytes": size}
+		})
+	}
 
 	// Start storage writer
+	writerDone := make(chan struct{})
 	go func() {
-		for results := range resultChan {
-			if err := fileStorage.Save(results); err != nil {
+		defer close(writerDone)
+		for results := range batches {
+			results = transforms.Apply(results)
+			promMetrics.Observe(results)
+			server.Observe(results)
+			if err := sink.Save(results); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to save results: %v\n", err)
 			}
+			if mirror != nil {
+				if err := mirror.Save(results); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to mirror results: %v\n", err)
+				}
+			}
+			if err := mon.SaveSnapshot(statePath); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to save state snapshot: %v\n", err)
+			}
 		}
 	}()
 
 	// Start web server in background
-	server := api.NewServer(dataDir, webDir)
+	serverErr := make(chan error, 1)
 	go func() {
-		if err := server.Start(webAddr); err != nil {
-			panic(err)
-		}
+		serverErr <- server.Start(cfg.WebAddr)
 	}()
 
-	// Wait for interrupt signal
+	// Wait for interrupt signal or a web server failure
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	select {
+	case <-sigChan:
+		fmt.Println("\nShutting down gracefully...")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := server.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Web server shutdown: %v\n", err)
+		}
+		cancel()
+	case err := <-serverErr:
+		fmt.Fprintf(os.Stderr, "Web server failed: %v\n", err)
+		exitCode = 1
+	}
 
-	fmt.Println("\nShutting down gracefully...")
+	// Let in-flight cycles finish, then drain the writer before the
+	// deferred closes flush and close storage
 	close(stopChan)
-	time.Sleep(1 * time.Second)
+	<-monitorDone
 	close(resultChan)
+	<-writerDone
 }
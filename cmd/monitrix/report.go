@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"monitrix/internal/api"
+	"monitrix/internal/config"
+	"monitrix/internal/storage"
+)
+
+// defaultReportTitle heads reports without REPORT_TITLE or --title
+const defaultReportTitle = "Network Availability Report"
+
+// runReport writes an SLA report for one calendar month as PDF or JSON.
+// It reads the local logs only and needs no network access.
+func runReport(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	format := flags.String("format", "pdf", "output format: pdf or json")
+	month := flags.String("month", "", "month to report on as YYYY-MM (default: last month)")
+	out := flags.String("out", "", "output file (default: stdout)")
+	title := flags.String("title", getEnv("REPORT_TITLE", defaultReportTitle), "report title")
+	logo := flags.String("logo", os.Getenv("REPORT_LOGO"), "PNG, JPEG or GIF logo for the header")
+	flags.Parse(args)
+
+	if *format != "pdf" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown report format %q, expected pdf or json\n", *format)
+		os.Exit(2)
+	}
+	if err := writeReport(*format, *month, *out, *title, *logo); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeReport builds the report for month and writes it to out
+func writeReport(format, month, out, title, logo string) error {
+	start, end, err := reportPeriod(month, time.Now())
+	if err != nil {
+		return err
+	}
+	objectives, err := getSLAObjectives()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(os.Getenv("MONITRIX_CONFIG"))
+	if err != nil {
+		return err
+	}
+	dataDir, _, err := defaultDirs()
+	if err != nil {
+		return err
+	}
+	dataDir = getEnv("DATA_DIR", dataDir)
+	if getEnv("SIMULATE", "false") == "true" {
+		dataDir = filepath.Join(dataDir, "simulated")
+	}
+	logs, err := readReportLogs(dataDir, cfg.Storage.Backend, start, end)
+	if err != nil {
+		return fmt.Errorf("reading logs: %w", err)
+	}
+	report := api.BuildReport(logs, getStatsOptions(getUpCriteria()), objectives, start, end)
+
+	// Render fully before touching the output, so a failure leaves no partial file
+	var render func(io.Writer) error
+	if format == "json" {
+		render = func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+	} else {
+		pdf, err := reportPDF(report, title, logo)
+		if err != nil {
+			return err
+		}
+		render = pdf.Output
+	}
+
+	if out == "" {
+		return render(os.Stdout)
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := render(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readReportLogs reads the period's logs from the storage backend
+func readReportLogs(dataDir, backend string, start, end time.Time) ([]storage.LogEntry, error) {
+	if backend != config.BackendSQLite {
+		return storage.ReadLogs(dataDir, &start, &end)
+	}
+	sqliteStorage, err := storage.NewSQLiteStorage(filepath.Join(dataDir, sqliteFile))
+	if err != nil {
+		return nil, err
+	}
+	defer sqliteStorage.Close()
+	return sqliteStorage.ReadLogs(&start, &end)
+}
+
+// reportPeriod returns the bounds of month (YYYY-MM, in the local timezone),
+// defaulting to the last full month. The current month ends at now.
+func reportPeriod(month string, now time.Time) (start, end time.Time, err error) {
+	if month == "" {
+		start = time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.Local)
+	} else if start, err = time.ParseInLocation("2006-01", month, time.Local); err != nil {
+		return start, end, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+	}
+	end = start.AddDate(0, 1, 0)
+	if !start.Before(now) {
+		return start, end, fmt.Errorf("month %s has not started yet", start.Format("2006-01"))
+	}
+	if end.After(now) {
+		end = now
+	}
+	return start, end, nil
+}
+
+// PDF layout, in millimetres on A4 portrait
+const (
+	pdfMargin     = 15.0
+	pdfLineHeight = 7.0
+	pdfLogoHeight = 16.0
+)
+
+// Table colours, as RGB
+var (
+	pdfHeaderFill = [3]int{230, 233, 240}
+	pdfMetColour  = [3]int{22, 130, 60}
+	pdfMissColour = [3]int{190, 30, 30}
+)
+
+// reportPDF lays out the report: a branded header, the overall attainment,
+// a per-host table and the list of outages
+func reportPDF(report api.Report, title, logo string) (*fpdf.Fpdf, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("Monitrix", true)
+	pdf.AliasNbPages("")
+	// Core fonts are built in, so text goes through their cp1252 encoding
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - page %d of {nb}", time.Now().Format("2006-01-02 15:04 MST"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Header: the logo on the left, title and period beside it
+	textX := pdfMargin
+	if logo != "" {
+		pdf.ImageOptions(logo, pdfMargin, pdfMargin, 0, pdfLogoHeight, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+		if err := pdf.Error(); err != nil {
+			return nil, fmt.Errorf("logo %s: %w", logo, err)
+		}
+		if info := pdf.GetImageInfo(logo); info != nil {
+			textX += info.Width()*pdfLogoHeight/info.Height() + 5
+		}
+	}
+	pdf.SetXY(textX, pdfMargin)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 9, tr(title), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.CellFormat(0, 6, reportPeriodLabel(report), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetY(max(pdf.GetY(), pdfMargin+pdfLogoHeight) + 6)
+
+	// Overall attainment
+	pdfSection(pdf, "Summary")
+	overall := report.Overall
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, pdfLineHeight, fmt.Sprintf("Internet uptime: %s against an objective of %s", uptimeLabel(overall), percentLabel(overall.Objective)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, pdfLineHeight, fmt.Sprintf("Total downtime: %s across %d outage(s)", durationLabel(overall.DowntimeSeconds), len(overall.Outages)), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdfStatusColour(pdf, overall)
+	pdf.CellFormat(0, pdfLineHeight, "SLA "+statusLabel(overall), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	// Per-host availability
+	pdfSection(pdf, "Availability by host")
+	hostWidths := []float64{60, 22, 26, 26, 26, 20}
+	pdfTableHeader(pdf, hostWidths, []string{"Host", "Checks", "Uptime", "Objective", "Downtime", "SLA"})
+	pdf.SetFont("Helvetica", "", 10)
+	for _, host := range report.Hosts {
+		pdf.CellFormat(hostWidths[0], pdfLineHeight, tr(host.Host), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(hostWidths[1], pdfLineHeight, fmt.Sprint(host.CheckCount), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(hostWidths[2], pdfLineHeight, uptimeLabel(host), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(hostWidths[3], pdfLineHeight, percentLabel(host.Objective), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(hostWidths[4], pdfLineHeight, durationLabel(host.DowntimeSeconds), "B", 0, "R", false, 0, "")
+		pdfStatusColour(pdf, host)
+		pdf.CellFormat(hostWidths[5], pdfLineHeight, statusLabel(host), "B", 1, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+	if len(report.Hosts) == 0 {
+		pdf.CellFormat(0, pdfLineHeight, "No checks were recorded in this period.", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Outages of the internet as a whole
+	pdfSection(pdf, "Outages")
+	outageWidths := []float64{36, 36, 24, 20, 64}
+	pdfTableHeader(pdf, outageWidths, []string{"Start", "End", "Duration", "Severity", "Failed hosts"})
+	pdf.SetFont("Helvetica", "", 9)
+	for _, outage := range overall.Outages {
+		if pdf.GetY()+pdfLineHeight > 297-pdfMargin {
+			pdf.AddPage()
+			pdfTableHeader(pdf, outageWidths, []string{"Start", "End", "Duration", "Severity", "Failed hosts"})
+			pdf.SetFont("Helvetica", "", 9)
+		}
+		hosts := strings.Join(outage.FailedHosts, ", ")
+		if len(hosts) > 48 {
+			hosts = hosts[:45] + "..."
+		}
+		pdf.CellFormat(outageWidths[0], pdfLineHeight, outage.StartTime.Local().Format("2006-01-02 15:04:05"), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(outageWidths[1], pdfLineHeight, outage.EndTime.Local().Format("2006-01-02 15:04:05"), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(outageWidths[2], pdfLineHeight, durationLabel(outage.Duration), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(outageWidths[3], pdfLineHeight, string(outage.Severity), "B", 0, "C", false, 0, "")
+		pdf.CellFormat(outageWidths[4], pdfLineHeight, tr(hosts), "B", 1, "L", false, 0, "")
+	}
+	if len(overall.Outages) == 0 {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, pdfLineHeight, "No outages were recorded in this period.", "", 1, "L", false, 0, "")
+	}
+
+	return pdf, pdf.Error()
+}
+
+// pdfSection writes a section heading
+func pdfSection(pdf *fpdf.Fpdf, heading string) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 9, heading, "", 1, "L", false, 0, "")
+}
+
+// pdfTableHeader writes a shaded header row
+func pdfTableHeader(pdf *fpdf.Fpdf, widths []float64, labels []string) {
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(pdfHeaderFill[0], pdfHeaderFill[1], pdfHeaderFill[2])
+	for i, label := range labels {
+		ln := 0
+		if i == len(labels)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(widths[i], pdfLineHeight, label, "B", ln, "L", true, 0, "")
+	}
+}
+
+// pdfStatusColour sets the text colour for a result's SLA status
+func pdfStatusColour(pdf *fpdf.Fpdf, result api.SLAResult) {
+	colour := pdfMissColour
+	if result.Met {
+		colour = pdfMetColour
+	}
+	pdf.SetTextColor(colour[0], colour[1], colour[2])
+}
+
+// reportPeriodLabel describes the reported range, e.g. "September 2026"
+func reportPeriodLabel(report api.Report) string {
+	label := report.Start.Format("January 2006")
+	if next := report.Start.AddDate(0, 1, 0); report.End.Before(next) {
+		label += " (to " + report.End.Format("2 Jan 15:04") + ")"
+	}
+	return label + ", " + report.Start.Format("MST")
+}
+
+// statusLabel reports whether a result met its objective
+func statusLabel(result api.SLAResult) string {
+	switch {
+	case result.CheckCount == 0:
+		return "no data"
+	case result.Met:
+		return "met"
+	default:
+		return "missed"
+	}
+}
+
+// uptimeLabel formats a result's uptime, or a dash without checks
+func uptimeLabel(result api.SLAResult) string {
+	if result.CheckCount == 0 {
+		return "-"
+	}
+	return percentLabel(result.UptimePercent)
+}
+
+// percentLabel formats a percentage with enough precision for "nines"
+func percentLabel(percent float64) string {
+	return fmt.Sprintf("%.3f%%", percent)
+}
+
+// durationLabel formats seconds as e.g. "1h 02m 05s"
+func durationLabel(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %02dm %02ds", int(d.Hours()), int(d.Minutes())%60, seconds%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm %02ds", int(d.Minutes()), seconds%60)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// terminalWidth returns fallback; the width can't be queried on this platform
+func terminalWidth(fallback int) int {
+	return fallback
+}
+
+// resizeSignals is empty: there's no resize signal on this platform
+var resizeSignals []os.Signal
@@ -0,0 +1,22 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalWidth returns the width of the terminal on stdout, or fallback
+func terminalWidth(fallback int) int {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 {
+		return fallback
+	}
+	return int(ws.Col)
+}
+
+// resizeSignals are the signals delivered when the terminal is resized
+var resizeSignals = []os.Signal{syscall.SIGWINCH}
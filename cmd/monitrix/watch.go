@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"monitrix/internal/config"
+	"monitrix/internal/monitor"
+)
+
+// ANSI sequences used to redraw the watch table in place
+const (
+	ansiClear      = "\033[H\033[2J"
+	ansiHideCursor = "\033[?25l"
+	ansiShowCursor = "\033[?25h"
+	ansiGreen      = "\033[32m"
+	ansiRed        = "\033[31m"
+	ansiYellow     = "\033[33m"
+	ansiReset      = "\033[0m"
+)
+
+// sparkBlocks renders latencies from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// watchHistory is how many results per host are kept for sparklines
+const watchHistory = 120
+
+// hostHistory holds a host's recent results, oldest first
+type hostHistory struct {
+	results []monitor.PingResult
+}
+
+// add appends a result, dropping the oldest beyond watchHistory
+func (h *hostHistory) add(result monitor.PingResult) {
+	h.results = append(h.results, result)
+	if len(h.results) > watchHistory {
+		h.results = h.results[len(h.results)-watchHistory:]
+	}
+}
+
+// sparkline renders the last width results, scaled to the slowest success.
+// Failures are drawn as a red cross.
+func (h *hostHistory) sparkline(width int) string {
+	results := h.results
+	if len(results) > width {
+		results = results[len(results)-width:]
+	}
+
+	var maxLatency int64
+	for _, r := range results {
+		if r.Success && r.Latency > maxLatency {
+			maxLatency = r.Latency
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range results {
+		if !r.Success {
+			b.WriteString(ansiRed + "✗" + ansiReset)
+			continue
+		}
+		level := 0
+		if maxLatency > 0 {
+			level = int(r.Latency * int64(len(sparkBlocks)-1) / maxLatency)
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// runWatch probes continuously and redraws a live status table in the
+// terminal, without writing files or starting the web server
+func runWatch() {
+	cfg, err := config.Load(os.Getenv("MONITRIX_CONFIG"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	hosts, interval := cfg.Hosts, cfg.Interval
+
+	simulation, err := getSimulation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid simulation configuration: %v\n", err)
+		os.Exit(1)
+	}
+	mon, err := newMonitor(os.Stdout, cfg.Hosts, cfg.Interval, cfg.Timeout, simulation)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid monitor configuration: %v\n", err)
+		os.Exit(1)
+	}
+	mon.Output = io.Discard
+
+	resultChan := make(chan []monitor.PingResult, 10)
+	stopChan := make(chan struct{})
+	go mon.Start(resultChan, stopChan)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	resizeChan := make(chan os.Signal, 1)
+	if len(resizeSignals) > 0 {
+		signal.Notify(resizeChan, resizeSignals...)
+	}
+
+	history := make(map[string]*hostHistory, len(hosts))
+	for _, host := range hosts {
+		history[host] = &hostHistory{}
+	}
+	var lastCycle time.Time
+
+	fmt.Print(ansiHideCursor)
+	defer fmt.Print(ansiShowCursor)
+
+	draw := func() {
+		drawWatch(os.Stdout, hosts, history, mon.UpCriteria, interval, lastCycle)
+	}
+	draw()
+
+	for {
+		select {
+		case results := <-resultChan:
+			for _, result := range results {
+				if h, ok := history[result.Host]; ok {
+					h.add(result)
+				}
+			}
+			lastCycle = time.Now()
+			draw()
+		case <-resizeChan:
+			draw()
+		case <-sigChan:
+			close(stopChan)
+			fmt.Println()
+			return
+		}
+	}
+}
+
+// drawWatch renders the status table for the latest results
+func drawWatch(w io.Writer, hosts []string, history map[string]*hostHistory, criteria monitor.UpCriteria, interval time.Duration, lastCycle time.Time) {
+	width := terminalWidth(80)
+	hostWidth := 20
+	for _, host := range hosts {
+		if len(host) > hostWidth {
+			hostWidth = len(host)
+		}
+	}
+	// host, status, latency and the gaps between them
+	sparkWidth := width - hostWidth - 6 - 10 - 4
+	if sparkWidth < 0 {
+		sparkWidth = 0
+	}
+
+	var b strings.Builder
+	b.WriteString(ansiClear)
+	b.WriteString("Monitrix watch — Ctrl-C to exit\n")
+
+	var latest []monitor.PingResult
+	for _, host := range hosts {
+		if h := history[host]; len(h.results) > 0 {
+			latest = append(latest, h.results[len(h.results)-1])
+		}
+	}
+	switch {
+	case lastCycle.IsZero():
+		b.WriteString(ansiYellow + "Waiting for first cycle..." + ansiReset + "\n\n")
+	default:
+		status := ansiGreen + "ONLINE" + ansiReset
+		if online, _ := monitor.IsInternetUp(latest, criteria); !online {
+			status = ansiRed + "OFFLINE" + ansiReset
+		}
+		fmt.Fprintf(&b, "Internet: %s   last check %s   every %v\n\n", status, lastCycle.Format("15:04:05"), interval)
+	}
+
+	fmt.Fprintf(&b, "%-*s  %-6s  %10s  %s\n", hostWidth, "HOST", "STATUS", "LATENCY", "HISTORY")
+	for _, host := range hosts {
+		h := history[host]
+		status, latency := ansiYellow+"  ..  "+ansiReset, ""
+		if len(h.results) > 0 {
+			last := h.results[len(h.results)-1]
+			switch {
+			case last.Paused:
+				status = ansiYellow + "PAUSED" + ansiReset
+			case last.Success:
+				status = ansiGreen + "  OK  " + ansiReset
+				latency = fmt.Sprintf("%dms", last.Latency)
+			default:
+				status = ansiRed + " FAIL " + ansiReset
+			}
+		}
+		fmt.Fprintf(&b, "%-*s  %s  %10s  %s\n", hostWidth, host, status, latency, h.sparkline(sparkWidth))
+	}
+
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,157 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Kind says whether an alert is for an outage starting or ending
+type Kind string
+
+const (
+	KindDown Kind = "downtime_start"
+	KindUp   Kind = "downtime_end"
+)
+
+// Alert describes an outage beginning or ending
+type Alert struct {
+	Kind        Kind       `json:"event"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     *time.Time `json:"end_time,omitempty"`         // set on recovery
+	Duration    int64      `json:"duration_seconds,omitempty"` // set on recovery
+	FailedHosts []string   `json:"failed_hosts"`
+	Severity    string     `json:"severity,omitempty"`
+	Diagnosis   string     `json:"diagnosis,omitempty"`
+}
+
+// Notifier delivers alerts somewhere a person will see them
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+// WebhookNotifier POSTs each alert as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// String returns the webhook URL
+func (n *WebhookNotifier) String() string {
+	return n.url
+}
+
+// Notify posts the alert, failing on any non-2xx response
+func (n *WebhookNotifier) Notify(alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	return post(n.client, n.url, data)
+}
+
+// post sends a JSON body to url
+func post(client *http.Client, url string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monitrix")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert endpoint responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// queueSize bounds the alerts waiting for delivery
+const queueSize = 32
+
+// Queue delivers alerts to its notifiers in order on a background
+// goroutine, so a slow endpoint never holds up monitoring. Failures are
+// reported on stderr.
+type Queue struct {
+	notifiers []Notifier
+	alerts    chan Alert
+	done      chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewQueue starts delivering to notifiers
+func NewQueue(notifiers ...Notifier) *Queue {
+	q := &Queue{
+		notifiers: notifiers,
+		alerts:    make(chan Alert, queueSize),
+		done:      make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Notify queues the alert, failing if the queue is full or closed
+func (q *Queue) Notify(alert Alert) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return fmt.Errorf("alert queue closed, dropping %s alert", alert.Kind)
+	}
+	select {
+	case q.alerts <- alert:
+		return nil
+	default:
+		return fmt.Errorf("alert queue full, dropping %s alert", alert.Kind)
+	}
+}
+
+// Depth returns how many alerts are waiting for delivery and how many the
+// queue holds
+func (q *Queue) Depth() (waiting, capacity int) {
+	return len(q.alerts), cap(q.alerts)
+}
+
+// run delivers queued alerts until Close
+func (q *Queue) run() {
+	defer close(q.done)
+	for alert := range q.alerts {
+		for _, notifier := range q.notifiers {
+			if err := notifier.Notify(alert); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to deliver %s alert to %v: %v\n", alert.Kind, notifier, err)
+			}
+		}
+	}
+}
+
+// Close delivers the alerts still queued and stops the queue
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.alerts)
+	}
+	q.mu.Unlock()
+	<-q.done
+	return nil
+}
@@ -0,0 +1,92 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Attachment colors for Slack messages
+const (
+	slackColorDown = "#d93025"
+	slackColorUp   = "#2e9e44"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook as Block Kit
+// messages: a red attachment when an outage starts and a green one when it ends
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier creates a notifier posting to a Slack incoming-webhook URL
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// String names the notifier without revealing the secret webhook URL
+func (n *SlackNotifier) String() string {
+	return "Slack"
+}
+
+// Notify posts the alert as a Slack message
+func (n *SlackNotifier) Notify(alert Alert) error {
+	data, err := json.Marshal(slackMessage(alert))
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	return post(n.client, n.url, data)
+}
+
+// slackMessage lays out an alert in Block Kit. The top-level text is the
+// notification fallback; the colored attachment holds the blocks.
+func slackMessage(alert Alert) map[string]any {
+	title := ":red_circle: Internet down"
+	color := slackColorDown
+	fields := []map[string]any{
+		slackField("Started", alert.StartTime.Local().Format("2006-01-02 15:04:05 MST")),
+	}
+	if alert.Kind == KindUp {
+		title = ":large_green_circle: Internet back up"
+		color = slackColorUp
+		fields = append(fields, slackField("Duration", (time.Duration(alert.Duration)*time.Second).String()))
+	}
+	hosts := "none"
+	if len(alert.FailedHosts) > 0 {
+		hosts = strings.Join(alert.FailedHosts, ", ")
+	}
+	fields = append(fields, slackField("Failed hosts", hosts))
+	if alert.Severity != "" {
+		fields = append(fields, slackField("Severity", alert.Severity))
+	}
+	if alert.Diagnosis != "" {
+		fields = append(fields, slackField("Probable cause", strings.ReplaceAll(alert.Diagnosis, "_", " ")))
+	}
+
+	return map[string]any{
+		"text": title,
+		"attachments": []map[string]any{{
+			"color": color,
+			"blocks": []map[string]any{
+				{
+					"type": "section",
+					"text": map[string]any{"type": "mrkdwn", "text": "*" + title + "*"},
+				},
+				{
+					"type":   "section",
+					"fields": fields,
+				},
+			},
+		}},
+	}
+}
+
+// slackField is one labelled value in a section's two-column fields
+func slackField(label, value string) map[string]any {
+	return map[string]any{"type": "mrkdwn", "text": "*" + label + "*\n" + value}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"monitrix/internal/storage"
+)
+
+// AddressChange records when a host's resolved address set changed
+type AddressChange struct {
+	Timestamp time.Time `json:"timestamp"`
+	Addresses []string  `json:"addresses"`
+	Previous  []string  `json:"previous,omitempty"` // empty for the first observation in range
+}
+
+// AddressHistory lists the address changes observed for one host
+type AddressHistory struct {
+	Host    string          `json:"host"`
+	Changes []AddressChange `json:"changes"`
+}
+
+// handleAddresses returns the history of a host's resolved IP addresses
+func (s *Server) handleAddresses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		http.Error(w, "Missing host parameter", http.StatusBadRequest)
+		return
+	}
+
+	startTime, endTime, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	logs, err := s.readLogs(w, startTime, endTime)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(AddressHistory{
+		Host:    host,
+		Changes: addressChanges(logs, host),
+	})
+}
+
+// addressChanges walks the logs and records each change in a host's address
+// set. Results without addresses (failed lookups, or recording disabled) are skipped.
+func addressChanges(logs []storage.LogEntry, host string) []AddressChange {
+	changes := []AddressChange{}
+	var current []string
+
+	for _, entry := range logs {
+		for _, result := range entry.Results {
+			if result.Host != host || len(result.Addresses) == 0 {
+				continue
+			}
+			if slices.Equal(result.Addresses, current) {
+				continue
+			}
+			changes = append(changes, AddressChange{
+				Timestamp: result.Timestamp,
+				Addresses: result.Addresses,
+				Previous:  current,
+			})
+			current = result.Addresses
+		}
+	}
+	return changes
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"time"
+
+	"monitrix/internal/storage"
+)
+
+// cycleCounts returns how many online, offline and paused cycles an entry
+// represents: a single cycle for raw entries, the whole window for roll-ups
+func cycleCounts(entry storage.LogEntry, online, paused bool) (onlineCycles, offlineCycles, pausedCycles int) {
+	if agg := entry.Aggregate; agg != nil {
+		return agg.OnlineCycles, agg.Cycles - agg.OnlineCycles - agg.PausedCycles, agg.PausedCycles
+	}
+	switch {
+	case paused:
+		return 0, 0, 1
+	case online:
+		return 1, 0, 0
+	default:
+		return 0, 1, 0
+	}
+}
+
+// entryStart returns when the cycles recorded in an entry began
+func entryStart(entry storage.LogEntry) time.Time {
+	if entry.Aggregate != nil {
+		return entry.Aggregate.Start
+	}
+	return entry.Timestamp
+}
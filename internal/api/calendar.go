@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// Calendar request bounds
+const (
+	defaultCalendarDays = 365
+	maxCalendarDays     = 3660
+)
+
+// CalendarDay summarises one calendar day. UptimePercent is null on days
+// without any checks, so they can be told apart from fully up days.
+type CalendarDay struct {
+	Date            string   `json:"date"` // YYYY-MM-DD in the requested timezone
+	UptimePercent   *float64 `json:"uptime_percent"`
+	DowntimeSeconds int64    `json:"downtime_seconds"`
+	CheckCount      int      `json:"check_count"`
+}
+
+// handleCalendar returns per-day uptime for the last `days` days, for a
+// single host or, without `host`, for the internet as a whole. Days follow
+// the server's local timezone (set with TZ) unless `tz` names another.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	days := defaultCalendarDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		n, err := strconv.Atoi(daysStr)
+		if err != nil || n < 1 || n > maxCalendarDays {
+			http.Error(w, fmt.Sprintf("Invalid days parameter, expected 1-%d", maxCalendarDays), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	loc := time.Local
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			http.Error(w, "Invalid tz parameter, expected an IANA zone like Europe/Berlin", http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, loc)
+
+	logs, err := s.readLogs(w, &start, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	opts := s.statsOpts
+	if host := r.URL.Query().Get("host"); host != "" {
+		logs = hostLogs(logs, host)
+		opts.Up.Rule = monitor.UpRuleAll
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendarDays(logs, opts, start, days, now))
+}
+
+// calendarDays buckets logs into consecutive days from start. Check counts
+// and uptime come from each day's cycles; downtime is taken from the
+// downtime events over the whole range, split at midnight.
+func calendarDays(logs []storage.LogEntry, opts StatsOptions, start time.Time, days int, now time.Time) []CalendarDay {
+	loc := start.Location()
+	calendar := make([]CalendarDay, days)
+	dayStarts := make([]time.Time, days+1)
+	for i := range dayStarts {
+		dayStarts[i] = time.Date(start.Year(), start.Month(), start.Day()+i, 0, 0, 0, 0, loc)
+	}
+	dayOf := func(t time.Time) int {
+		t = t.In(loc)
+		return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Sub(start).Hours()+12) / 24
+	}
+
+	online, offline := make([]int, days), make([]int, days)
+	view := cycleView{interval: opts.ProbeInterval}
+	for _, entry := range logs {
+		judged := view.results(entry)
+		day := dayOf(entry.Timestamp)
+		if day < 0 || day >= days {
+			continue
+		}
+		up, _, _ := opts.evaluateCycle(judged)
+		onlineCycles, offlineCycles, _ := cycleCounts(entry, up, isPaused(entry))
+		online[day] += onlineCycles
+		offline[day] += offlineCycles
+		if entry.Aggregate != nil && onlineCycles > 0 {
+			// Outages within a daily summary only have a total
+			calendar[day].DowntimeSeconds += entry.Aggregate.DowntimeSeconds
+		}
+	}
+
+	// Every event counts towards the day totals, regardless of list bounds
+	opts.MinDowntime = 0
+	opts.MaxDowntimeEvents = 0
+	for _, event := range calculateStats(logs, opts).DowntimeEvents {
+		end := now
+		if event.EndTime != nil {
+			end = *event.EndTime
+		}
+		for day := max(dayOf(event.StartTime), 0); day < days && dayStarts[day].Before(end); day++ {
+			from, to := event.StartTime, end
+			if from.Before(dayStarts[day]) {
+				from = dayStarts[day]
+			}
+			if to.After(dayStarts[day+1]) {
+				to = dayStarts[day+1]
+			}
+			if overlap := to.Sub(from); overlap > 0 {
+				calendar[day].DowntimeSeconds += int64(overlap.Seconds())
+			}
+		}
+	}
+
+	for i := range calendar {
+		calendar[i].Date = dayStarts[i].Format("2006-01-02")
+		calendar[i].CheckCount = online[i] + offline[i]
+		if calendar[i].CheckCount > 0 {
+			uptime := float64(online[i]) / float64(calendar[i].CheckCount) * 100
+			calendar[i].UptimePercent = &uptime
+		}
+	}
+	return calendar
+}
+
+// hostLogs reduces each entry to the given host's result, so cycle-level
+// statistics describe that host alone. Entries without it are dropped.
+func hostLogs(logs []storage.LogEntry, host string) []storage.LogEntry {
+	filtered := make([]storage.LogEntry, 0, len(logs))
+	for _, entry := range logs {
+		for _, result := range entry.Results {
+			if result.Host != host {
+				continue
+			}
+			hostEntry := storage.LogEntry{
+				Timestamp: entry.Timestamp,
+				Results:   []monitor.PingResult{result},
+			}
+			if entry.Aggregate != nil && result.Aggregate != nil {
+				hostEntry.Aggregate = &storage.EntryAggregate{
+					Start:        entry.Aggregate.Start,
+					Cycles:       entry.Aggregate.Cycles,
+					OnlineCycles: result.Aggregate.Successes,
+					PausedCycles: entry.Aggregate.Cycles - result.Aggregate.Cycles,
+				}
+			}
+			filtered = append(filtered, hostEntry)
+			break
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressed opts a handler into gzip compression. Responses smaller than
+// the server's minimum size are sent as-is, since compressing them costs
+// more than it saves.
+func (s *Server) compressed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if s.compressMin <= 0 || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: s.compressMin, status: http.StatusOK}
+		defer gw.close()
+		next(gw, r)
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip: listed
+// as gzip or x-gzip, or covered by "*", with a q-value above zero. An
+// explicit gzip entry wins over "*", so "*, gzip;q=0" refuses gzip.
+func acceptsGzip(header string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "x-gzip" && coding != "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil {
+					parsed = 0 // unparseable weights don't count as acceptance
+				}
+				q = parsed
+			}
+		}
+
+		if coding == "*" {
+			wildcard = q > 0
+			continue
+		}
+		return q > 0
+	}
+	return wildcard
+}
+
+// gzipResponseWriter buffers output until it reaches minSize, then switches
+// to gzip. Smaller responses are written uncompressed on close.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	buf     []byte
+	gz      *gzip.Writer
+}
+
+// WriteHeader defers the status until the encoding is decided
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush pushes compressed data to the client. A stream being flushed is
+// compressed even before reaching minSize, since the encoding can't change
+// once bytes have been sent.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz == nil {
+		if err := w.startGzip(); err != nil {
+			return
+		}
+	}
+	w.gz.Flush()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// startGzip sends the headers and buffered output through a gzip writer
+func (w *gzipResponseWriter) startGzip() error {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", "gzip")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// close finishes the response, writing small bodies uncompressed
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.buf)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"x-gzip", true},
+		{"deflate, br", false},
+		{"gzip, deflate, br", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"gzip;q=0.5", true},
+		{"deflate;q=1, gzip;q=0.001", true},
+		{"br, gzip;q=0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"*, gzip;q=0", false},
+		{"gzip;q=0, *", false},
+		{"identity, *;q=0.1", true},
+		{"gzip;q=abc", false},
+		{"gzipped", false},
+	}
+	for _, test := range tests {
+		if got := acceptsGzip(test.header); got != test.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", test.header, got, test.want)
+		}
+	}
+}
+
+func TestCompressedHonoursZeroWeight(t *testing.T) {
+	s := &Server{compressMin: 10}
+	handler := s.compressed(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	})
+
+	for header, want := range map[string]string{"gzip": "gzip", "gzip;q=0": ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", header)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if got := rec.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", header, got, want)
+		}
+	}
+}
+
+// BenchmarkLogsCompression serves a day of one-minute cycles over five
+// hosts from /api/logs with and without gzip and reports the response size
+func BenchmarkLogsCompression(b *testing.B) {
+	store, err := storage.NewFileStorage(b.TempDir(), storage.WriteHeldOpen)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer store.Close()
+
+	start := time.Now().Add(-23 * time.Hour)
+	for i := 0; i < 24*60-90; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		results := make([]monitor.PingResult, 5)
+		for j := range results {
+			results[j] = monitor.PingResult{
+				Host:      fmt.Sprintf("host%d.example", j),
+				Timestamp: at,
+				Success:   true,
+				Latency:   int64(20 + (i*7+j*13)%60),
+			}
+		}
+		if err := store.SaveEntry(storage.LogEntry{Timestamp: at, Results: results}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	s := NewServer(store, "", Options{CompressMinBytes: 1024})
+	handler := s.compressed(s.handleLogs)
+	for _, encoding := range []string{"identity", "gzip"} {
+		b.Run(encoding, func(b *testing.B) {
+			var size int
+			for b.Loop() {
+				req := httptest.NewRequest(http.MethodGet, "/api/logs", nil)
+				req.Header.Set("Accept-Encoding", encoding)
+				rec := httptest.NewRecorder()
+				handler(rec, req)
+				if rec.Code != http.StatusOK {
+					b.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+				}
+				size = rec.Body.Len()
+			}
+			b.ReportMetric(float64(size), "bytes/response")
+		})
+	}
+}
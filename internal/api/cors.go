@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+)
+
+// withCORS applies the CORS policy to every response and answers
+// preflight requests. A listed origin is echoed back with credentials
+// allowed; "*" allows any origin without credentials, as browsers don't
+// send credentials to wildcard origins.
+func (s *Server) withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		allowed := origin != "" && slices.Contains(s.corsOrigins, origin)
+		switch {
+		case allowed:
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		case slices.Contains(s.corsOrigins, "*"):
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			allowed = origin != ""
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept")
+				w.Header().Set("Access-Control-Max-Age", "600")
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"time"
+
+	"monitrix/internal/storage"
+)
+
+// cycleCounter counts evaluated cycles and tracks the current streak of
+// them. Each host is probed on its own ticker, so an entry holds only one
+// host's result; a cycle closes once a host reports again, by which time
+// every host has had its turn, so the counts don't grow with the number of
+// hosts. Entries holding every host, as written before per-host tickers,
+// are a cycle each. A cycle is offline if the internet was judged down at
+// any entry in it.
+type cycleCounter struct {
+	online  int
+	offline int
+	streak  streakTracker
+
+	seen  map[string]bool // source and host of every result in the open cycle
+	open  bool
+	up    bool
+	start time.Time
+	end   time.Time
+}
+
+// add folds in a raw entry judged online or offline
+func (c *cycleCounter) add(entry storage.LogEntry, online bool) {
+	for _, result := range entry.Results {
+		if c.seen[entry.Source+"\x00"+result.Host] {
+			c.flush()
+			break
+		}
+	}
+	if !c.open {
+		c.open = true
+		c.up = true
+		c.start = entry.Timestamp
+		if c.seen == nil {
+			c.seen = make(map[string]bool)
+		}
+	}
+	c.up = c.up && online
+	c.end = entry.Timestamp
+	for _, result := range entry.Results {
+		c.seen[entry.Source+"\x00"+result.Host] = true
+	}
+}
+
+// addRollup closes the open cycle and counts a roll-up's cycles as recorded
+func (c *cycleCounter) addRollup(entry storage.LogEntry, onlineCycles, offlineCycles int) {
+	c.flush()
+	c.online += onlineCycles
+	c.offline += offlineCycles
+	mixed := onlineCycles > 0 && offlineCycles > 0
+	if onlineCycles > 0 {
+		c.streak.add(true, onlineCycles, entryStart(entry), entry.Timestamp, mixed)
+	} else {
+		c.streak.add(false, offlineCycles, entryStart(entry), entry.Timestamp, mixed)
+	}
+}
+
+// flush counts the open cycle, if any
+func (c *cycleCounter) flush() {
+	if !c.open {
+		return
+	}
+	c.open = false
+	clear(c.seen)
+	if c.up {
+		c.online++
+	} else {
+		c.offline++
+	}
+	c.streak.add(c.up, 1, c.start, c.end, false)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"monitrix/internal/clock"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+func TestCalculateStatsCountsCyclesNotHosts(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	hosts := []string{"a", "b", "c"}
+
+	// Each host on its own ticker: one entry per host per cycle, with host c
+	// failing throughout, which leaves the internet up
+	var logs []storage.LogEntry
+	for cycle := range 4 {
+		for i, host := range hosts {
+			at := start.Add(time.Duration(cycle)*30*time.Second + time.Duration(i)*time.Second)
+			result := monitor.PingResult{Host: host, Timestamp: at, Success: host != "c"}
+			if !result.Success {
+				result.Error = "i/o timeout"
+			}
+			logs = append(logs, storage.LogEntry{Timestamp: at, Results: []monitor.PingResult{result}})
+		}
+	}
+
+	stats := calculateStats(logs, StatsOptions{Clock: clock.NewFakeClock(start.Add(2 * time.Minute))})
+	if stats.TotalChecks != 4 || stats.OnlineChecks != 4 {
+		t.Errorf("total = %d, online = %d; want 4 cycles of 3 hosts each", stats.TotalChecks, stats.OnlineChecks)
+	}
+	if streak := stats.CurrentSuccessStreak; streak == nil || streak.Checks != 4 {
+		t.Errorf("success streak = %+v, want 4 cycles", streak)
+	}
+}
+
+func TestCycleCounterLegacyEntries(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var counter cycleCounter
+	for i, online := range []bool{true, false, false} {
+		counter.add(storage.LogEntry{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Results:   []monitor.PingResult{{Host: "a"}, {Host: "b"}},
+		}, online)
+	}
+	counter.flush()
+
+	if counter.online != 1 || counter.offline != 2 {
+		t.Errorf("online = %d, offline = %d; want every all-host entry a cycle", counter.online, counter.offline)
+	}
+	if _, failure := counter.streak.current(); failure == nil || failure.Checks != 2 {
+		t.Errorf("failure streak = %+v, want 2 cycles", failure)
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// RegisterDebugState adds a named source to the /api/debug/state snapshot.
+// Each source is responsible for taking its own snapshot under whatever
+// locks protect its state. The server registers "outage_tracker" itself.
+func (s *Server) RegisterDebugState(name string, source func() any) {
+	s.debugMu.Lock()
+	defer s.debugMu.Unlock()
+
+	s.debugSources[name] = source
+}
+
+// handleDebugState returns a snapshot of the running system's internal state.
+// Sources are read one after another, each consistent on its own; there is
+// no lock across them, so e.g. a cycle can land between the monitor's
+// snapshot and the outage tracker's.
+func (s *Server) handleDebugState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.debugMu.Lock()
+	defer s.debugMu.Unlock()
+
+	state := make(map[string]any, len(s.debugSources)+1)
+	state["generated_at"] = time.Now()
+	for name, source := range s.debugSources {
+		state[name] = source()
+	}
+
+	json.NewEncoder(w).Encode(state)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+func TestDebugStateReportsTrackedOutage(t *testing.T) {
+	s := NewServer(nil, "", Options{AuthToken: "secret"})
+	s.RegisterDebugState("extra", func() any { return map[string]int{"depth": 3} })
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.transitions.observe([]monitor.PingResult{{Host: "a", Timestamp: at, Error: "i/o timeout"}}, at)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/debug/state", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var state struct {
+		Outage outageState    `json:"outage_tracker"`
+		Extra  map[string]int `json:"extra"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if !state.Outage.Active || state.Outage.StartTime == nil || !state.Outage.StartTime.Equal(at) {
+		t.Errorf("outage_tracker = %+v, want an outage active since %v", state.Outage, at)
+	}
+	if state.Extra["depth"] != 3 {
+		t.Errorf("registered source missing: %+v", state.Extra)
+	}
+}
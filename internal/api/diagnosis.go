@@ -0,0 +1,57 @@
+package api
+
+import "monitrix/internal/monitor"
+
+// Diagnosis is the probable cause of a downtime event, judged from the
+// error codes of the probes that failed during it
+type Diagnosis string
+
+const (
+	// DiagnosisConnect: names resolved but connections failed, likely a
+	// firewall or routing problem
+	DiagnosisConnect Diagnosis = "connect_failure"
+	// DiagnosisDNS: resolution failed while resolved or literal addresses
+	// stayed reachable, likely a resolver problem
+	DiagnosisDNS Diagnosis = "dns_failure"
+	// DiagnosisFull: both resolution and connections failed
+	DiagnosisFull Diagnosis = "full_outage"
+)
+
+// failureCauses counts the kinds of probe failure seen during an event
+type failureCauses struct {
+	dns     int
+	connect int
+}
+
+// add counts the failed results of one offline cycle
+func (c *failureCauses) add(results []monitor.PingResult) {
+	for _, result := range results {
+		code := result.Code()
+		switch {
+		case code.IsDNS():
+			c.dns++
+		case code.IsConnect():
+			c.connect++
+		}
+	}
+}
+
+// merge adds the counts of another event
+func (c *failureCauses) merge(other failureCauses) {
+	c.dns += other.dns
+	c.connect += other.connect
+}
+
+// diagnosis returns the probable cause, or "" when no failure was
+// recognisably DNS or connection related (e.g. proxy or simulated failures)
+func (c failureCauses) diagnosis() Diagnosis {
+	switch {
+	case c.dns > 0 && c.connect > 0:
+		return DiagnosisFull
+	case c.dns > 0:
+		return DiagnosisDNS
+	case c.connect > 0:
+		return DiagnosisConnect
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// defaultDiffTolerance is how far from a requested timestamp a cycle may be
+const defaultDiffTolerance = 5 * time.Minute
+
+// HostDiff describes how a single host changed between two cycles
+type HostDiff struct {
+	Host           string              `json:"host"`
+	Before         *monitor.PingResult `json:"before,omitempty"` // nil if the host wasn't probed
+	After          *monitor.PingResult `json:"after,omitempty"`
+	StatusChanged  bool                `json:"status_changed"`
+	LatencyDeltaMs *int64              `json:"latency_delta_ms,omitempty"` // set when both probes succeeded
+}
+
+// CycleDiff compares two monitoring cycles host by host
+type CycleDiff struct {
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	FromOnline bool       `json:"from_online"`
+	ToOnline   bool       `json:"to_online"`
+	Hosts      []HostDiff `json:"hosts"`
+}
+
+// handleDiff shows which hosts changed state between the cycles nearest to two timestamps
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid or missing from parameter, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid or missing to parameter, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	tolerance := defaultDiffTolerance
+	if tolStr := r.URL.Query().Get("tolerance"); tolStr != "" {
+		if tolerance, err = time.ParseDuration(tolStr); err != nil || tolerance <= 0 {
+			http.Error(w, "Invalid tolerance parameter, expected a positive duration like 2m", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rangeStart, rangeEnd := from, to
+	if rangeEnd.Before(rangeStart) {
+		rangeStart, rangeEnd = rangeEnd, rangeStart
+	}
+	rangeStart = rangeStart.Add(-tolerance)
+	rangeEnd = rangeEnd.Add(tolerance)
+
+	logs, err := s.readLogs(w, &rangeStart, &rangeEnd)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Each host is probed on its own ticker, so compare every host's latest
+	// result at either end rather than only those in the nearest entry
+	view := cycleView{interval: s.statsOpts.ProbeInterval}
+	for i := range logs {
+		logs[i].Results = view.results(logs[i])
+	}
+
+	before := nearestEntry(logs, from, tolerance)
+	if before == nil {
+		http.Error(w, fmt.Sprintf("No cycle within %v of from=%s", tolerance, from.Format(time.RFC3339)), http.StatusNotFound)
+		return
+	}
+	after := nearestEntry(logs, to, tolerance)
+	if after == nil {
+		http.Error(w, fmt.Sprintf("No cycle within %v of to=%s", tolerance, to.Format(time.RFC3339)), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(diffCycles(*before, *after, s.statsOpts))
+}
+
+// nearestEntry returns the entry closest to t, or nil if none is within tolerance
+func nearestEntry(logs []storage.LogEntry, t time.Time, tolerance time.Duration) *storage.LogEntry {
+	var nearest *storage.LogEntry
+	var nearestDistance time.Duration
+
+	for i := range logs {
+		distance := logs[i].Timestamp.Sub(t).Abs()
+		if distance > tolerance {
+			continue
+		}
+		if nearest == nil || distance < nearestDistance {
+			nearest = &logs[i]
+			nearestDistance = distance
+		}
+	}
+	return nearest
+}
+
+// diffCycles compares the results of two cycles host by host
+func diffCycles(before, after storage.LogEntry, opts StatsOptions) CycleDiff {
+	fromOnline, _, _ := opts.evaluateCycle(before.Results)
+	toOnline, _, _ := opts.evaluateCycle(after.Results)
+
+	diff := CycleDiff{
+		From:       before.Timestamp,
+		To:         after.Timestamp,
+		FromOnline: fromOnline,
+		ToOnline:   toOnline,
+		Hosts:      []HostDiff{},
+	}
+
+	index := make(map[string]int)
+	hostDiff := func(host string) *HostDiff {
+		if i, ok := index[host]; ok {
+			return &diff.Hosts[i]
+		}
+		index[host] = len(diff.Hosts)
+		diff.Hosts = append(diff.Hosts, HostDiff{Host: host})
+		return &diff.Hosts[len(diff.Hosts)-1]
+	}
+
+	for _, result := range before.Results {
+		r := result
+		hostDiff(result.Host).Before = &r
+	}
+	for _, result := range after.Results {
+		r := result
+		hostDiff(result.Host).After = &r
+	}
+
+	for i := range diff.Hosts {
+		d := &diff.Hosts[i]
+		if d.Before == nil || d.After == nil {
+			d.StatusChanged = true
+			continue
+		}
+		d.StatusChanged = d.Before.Success != d.After.Success
+		if d.Before.Success && d.After.Success {
+			delta := d.After.Latency - d.Before.Latency
+			d.LatencyDeltaMs = &delta
+		}
+	}
+
+	return diff
+}
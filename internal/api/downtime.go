@@ -0,0 +1,210 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// DowntimeSampling selects which events survive the downtime event cap
+type DowntimeSampling string
+
+const (
+	// SampleLongest keeps the longest events
+	SampleLongest DowntimeSampling = "longest"
+	// SampleEven keeps events spread evenly across the range
+	SampleEven DowntimeSampling = "even"
+)
+
+// downtimeTracker turns a chronological sequence of online/offline
+// observations into downtime events
+type downtimeTracker struct {
+	active       bool
+	start        time.Time
+	failedHosts  []string
+	degraded     []string
+	spansGap     bool
+	causes       failureCauses
+	events       []DowntimeEvent
+	totalSeconds int64
+}
+
+// down records an offline observation, opening a new event if none is active.
+// degradedHosts are hosts refused under the degraded policy, which count as up.
+func (t *downtimeTracker) down(at time.Time, failedHosts, degradedHosts []string, results []monitor.PingResult) {
+	if !t.active {
+		t.active = true
+		t.start = at
+		t.failedHosts = failedHosts
+		t.degraded = degradedHosts
+		t.spansGap = false
+		t.causes = failureCauses{}
+	}
+	t.causes.add(results)
+}
+
+// bridge marks the active event, if any, as continuing across a monitoring gap
+func (t *downtimeTracker) bridge() {
+	if t.active {
+		t.spansGap = true
+	}
+}
+
+// up closes the active event, if any, at the given time
+func (t *downtimeTracker) up(at time.Time) {
+	if !t.active {
+		return
+	}
+	endTime := at
+	if endTime.Before(t.start) {
+		endTime = t.start
+	}
+	duration := elapsedSeconds(t.start, at)
+	t.totalSeconds += duration
+
+	t.events = append(t.events, DowntimeEvent{
+		StartTime:     t.start,
+		EndTime:       &endTime,
+		Duration:      duration,
+		IsOngoing:     false,
+		FailedHosts:   t.failedHosts,
+		DegradedHosts: t.degraded,
+		SpansGap:      t.spansGap,
+		Diagnosis:     t.causes.diagnosis(),
+		causes:        t.causes,
+	})
+	t.active = false
+}
+
+// finish records the active event, if any, as ongoing at now
+func (t *downtimeTracker) finish(now time.Time) {
+	if !t.active {
+		return
+	}
+	duration := elapsedSeconds(t.start, now)
+	t.totalSeconds += duration
+
+	t.events = append(t.events, DowntimeEvent{
+		StartTime:     t.start,
+		EndTime:       nil,
+		Duration:      duration,
+		IsOngoing:     true,
+		FailedHosts:   t.failedHosts,
+		DegradedHosts: t.degraded,
+		SpansGap:      t.spansGap,
+		Diagnosis:     t.causes.diagnosis(),
+		causes:        t.causes,
+	})
+	t.active = false
+}
+
+// elapsedSeconds returns the whole seconds from start to end. Log timestamps
+// are wall-clock readings, so a backward clock step (e.g. an NTP correction)
+// can put end before start; such durations are clamped to zero. This runs on
+// every stats or report request covering the event, so it doesn't log.
+func elapsedSeconds(start, end time.Time) int64 {
+	elapsed := end.Sub(start)
+	if elapsed < 0 {
+		return 0
+	}
+	return int64(elapsed.Seconds())
+}
+
+// mergeDowntime collapses chronologically ordered events separated by less
+// than gap into single intermittent events. A merged event spans from the
+// first outage's start to the last one's end, while its Duration remains the
+// total time actually offline.
+func mergeDowntime(events []DowntimeEvent, gap time.Duration) []DowntimeEvent {
+	if gap <= 0 || len(events) < 2 {
+		return events
+	}
+
+	merged := []DowntimeEvent{events[0]}
+	for _, event := range events[1:] {
+		last := &merged[len(merged)-1]
+		if last.EndTime == nil || event.StartTime.Sub(*last.EndTime) >= gap {
+			merged = append(merged, event)
+			continue
+		}
+
+		if last.MergedEvents == 0 {
+			last.MergedEvents = 1
+		}
+		last.MergedEvents++
+		last.Intermittent = true
+		last.EndTime = event.EndTime
+		last.IsOngoing = event.IsOngoing
+		last.Duration += event.Duration
+		last.FailedHosts = unionHosts(last.FailedHosts, event.FailedHosts)
+		last.DegradedHosts = unionHosts(last.DegradedHosts, event.DegradedHosts)
+		last.SpansGap = last.SpansGap || event.SpansGap
+		last.causes.merge(event.causes)
+		last.Diagnosis = last.causes.diagnosis()
+	}
+	return merged
+}
+
+// unionHosts returns a followed by the hosts of b not already in a
+func unionHosts(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	result := append([]string(nil), a...)
+	for _, host := range a {
+		seen[host] = true
+	}
+	for _, host := range b {
+		if !seen[host] {
+			seen[host] = true
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// boundDowntime drops events shorter than opts.MinDowntime and caps the rest
+// at opts.MaxDowntimeEvents, returning the kept events in their original
+// order and how many were omitted
+func boundDowntime(events []DowntimeEvent, opts StatsOptions) ([]DowntimeEvent, int) {
+	kept := events
+	if opts.MinDowntime > 0 {
+		kept = make([]DowntimeEvent, 0, len(events))
+		for _, event := range events {
+			if time.Duration(event.Duration)*time.Second >= opts.MinDowntime {
+				kept = append(kept, event)
+			}
+		}
+	}
+
+	limit := opts.MaxDowntimeEvents
+	if limit <= 0 || len(kept) <= limit {
+		return kept, len(events) - len(kept)
+	}
+
+	indices := make([]int, 0, limit)
+	if opts.DowntimeSampling == SampleEven {
+		// Evenly spaced picks, always including the first and last event
+		for i := 0; i < limit; i++ {
+			if limit == 1 {
+				indices = append(indices, 0)
+				break
+			}
+			indices = append(indices, i*(len(kept)-1)/(limit-1))
+		}
+	} else {
+		order := make([]int, len(kept))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return kept[order[a]].Duration > kept[order[b]].Duration
+		})
+		indices = append(indices, order[:limit]...)
+		sort.Ints(indices)
+	}
+
+	bounded := make([]DowntimeEvent, len(indices))
+	for i, idx := range indices {
+		bounded[i] = kept[idx]
+	}
+	return bounded, len(events) - len(bounded)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"monitrix/internal/clock"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+func TestElapsedSeconds(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		end  time.Time
+		want int64
+	}{
+		{"forward", start.Add(90 * time.Second), 90},
+		{"partial second truncated", start.Add(1500 * time.Millisecond), 1},
+		{"same instant", start, 0},
+		{"backward step clamped", start.Add(-5 * time.Minute), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := elapsedSeconds(start, test.end); got != test.want {
+				t.Errorf("elapsedSeconds = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDowntimeTrackerBackwardClockStep(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	stepped := start.Add(-30 * time.Second) // NTP moved the clock back mid-outage
+
+	t.Run("recovered", func(t *testing.T) {
+		var tracker downtimeTracker
+		tracker.down(start, []string{"a"}, nil, nil)
+		tracker.up(stepped)
+
+		if len(tracker.events) != 1 {
+			t.Fatalf("got %d events, want 1", len(tracker.events))
+		}
+		event := tracker.events[0]
+		if event.Duration != 0 || tracker.totalSeconds != 0 {
+			t.Errorf("duration = %d, total = %d; want both clamped to 0", event.Duration, tracker.totalSeconds)
+		}
+		if event.EndTime == nil || event.EndTime.Before(event.StartTime) {
+			t.Errorf("end %v before start %v", event.EndTime, event.StartTime)
+		}
+	})
+
+	t.Run("ongoing", func(t *testing.T) {
+		var tracker downtimeTracker
+		tracker.down(start, []string{"a"}, nil, nil)
+		tracker.finish(stepped)
+
+		if len(tracker.events) != 1 || !tracker.events[0].IsOngoing {
+			t.Fatalf("got %+v, want one ongoing event", tracker.events)
+		}
+		if got := tracker.events[0].Duration; got != 0 {
+			t.Errorf("ongoing duration = %d, want 0", got)
+		}
+	})
+
+	t.Run("later outage unaffected", func(t *testing.T) {
+		var tracker downtimeTracker
+		tracker.down(start, []string{"a"}, nil, nil)
+		tracker.up(stepped)
+		tracker.down(stepped.Add(time.Minute), []string{"a"}, nil, nil)
+		tracker.up(stepped.Add(3 * time.Minute))
+
+		if tracker.totalSeconds != 120 {
+			t.Errorf("total = %d, want 120 from the second outage alone", tracker.totalSeconds)
+		}
+	})
+}
+
+func TestDowntimeEventKeepsDegradedHostsApart(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var tracker downtimeTracker
+	tracker.down(start, []string{"a"}, []string{"b"}, nil)
+	tracker.up(start.Add(time.Minute))
+
+	event := tracker.events[0]
+	if !slices.Equal(event.FailedHosts, []string{"a"}) {
+		t.Errorf("failed hosts = %v, want [a]", event.FailedHosts)
+	}
+	if !slices.Equal(event.DegradedHosts, []string{"b"}) {
+		t.Errorf("degraded hosts = %v, want [b]", event.DegradedHosts)
+	}
+}
+
+func TestCalculateStatsCountsDailySummaryDowntime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(23 * time.Hour)
+	summary := storage.LogEntry{
+		Timestamp: end,
+		Results: []monitor.PingResult{{
+			Host: "a", Timestamp: end, Success: true,
+			Aggregate: &monitor.ResultAggregate{Cycles: 100, Successes: 98},
+		}},
+		Aggregate: &storage.EntryAggregate{Start: start, Cycles: 100, OnlineCycles: 98, DowntimeSeconds: 90},
+	}
+
+	stats := calculateStats([]storage.LogEntry{summary}, StatsOptions{Clock: clock.NewFakeClock(end)})
+	if stats.TotalDowntimeHours != 90.0/3600 {
+		t.Errorf("downtime = %vh, want the summary's 90s", stats.TotalDowntimeHours)
+	}
+	if stats.OnlineChecks != 98 || stats.OfflineChecks != 2 {
+		t.Errorf("online = %d, offline = %d; want the summary's cycles", stats.OnlineChecks, stats.OfflineChecks)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// Observe feeds a completed cycle's results to the live streams and marks
+// monitoring as progressing for /healthz. It is called by the result
+// writer, once per stored batch. The stats clock is read once, so the
+// health check, the published entry and any transition share one timestamp.
+func (s *Server) Observe(results []monitor.PingResult) {
+	now := s.statsOpts.now()
+	s.lastCheck.Store(now.UnixNano())
+	s.resultStream.publish("entry", storage.LogEntry{Timestamp: now, Results: results})
+	if transition := s.transitions.observe(results, now); transition != nil {
+		if transition.kind == transitionDown {
+			s.downtimes.Add(1)
+		}
+		s.downtimeStream.publish(transition.kind, transition.event)
+		if s.alerts != nil {
+			if err := s.alerts.Notify(transition.alert()); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to send alert: %v\n", err)
+			}
+		}
+	}
+}
+
+// DowntimeEvents returns how many outages have started, including those
+// counted by SeedDowntimes
+func (s *Server) DowntimeEvents() int64 {
+	return s.downtimes.Load()
+}
+
+// SeedDowntimes adds the outages that started in stored logs to
+// DowntimeEvents, so the count carries on after a restart. The logs are
+// replayed through a watcher of their own, leaving live transitions as they
+// were.
+func (s *Server) SeedDowntimes(logs []storage.LogEntry) {
+	replay := transitionWatcher{opts: s.statsOpts, view: cycleView{interval: s.statsOpts.ProbeInterval}}
+	for _, entry := range logs {
+		if transition := replay.observe(entry.Results, entry.Timestamp); transition != nil && transition.kind == transitionDown {
+			s.downtimes.Add(1)
+		}
+	}
+}
+
+// handleDowntimeStream streams outage transitions as server-sent events:
+// downtime_start with the ongoing DowntimeEvent when the internet goes
+// down, and downtime_end with the closed event when it comes back
+func (s *Server) handleDowntimeStream(w http.ResponseWriter, r *http.Request) {
+	serveStream(w, r, &s.downtimeStream)
+}
+
+// handleResultStream streams every stored batch of results as an entry
+// event carrying the JSON LogEntry, as it would appear in /api/logs
+func (s *Server) handleResultStream(w http.ResponseWriter, r *http.Request) {
+	serveStream(w, r, &s.resultStream)
+}
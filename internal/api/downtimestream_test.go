@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"monitrix/internal/clock"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+func TestObserveUsesOneClockReading(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewServer(nil, "", Options{Stats: StatsOptions{Clock: clock.NewFakeClock(at)}})
+	entries := s.resultStream.subscribe()
+	downtimes := s.downtimeStream.subscribe()
+
+	s.Observe([]monitor.PingResult{{Host: "a", Timestamp: at.Add(-time.Second), Error: "i/o timeout"}})
+
+	if got := time.Unix(0, s.lastCheck.Load()); !got.Equal(at) {
+		t.Errorf("last check = %v, want %v", got, at)
+	}
+
+	var entry storage.LogEntry
+	if err := json.Unmarshal((<-entries).data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if !entry.Timestamp.Equal(at) {
+		t.Errorf("published entry stamped %v, want %v", entry.Timestamp, at)
+	}
+
+	message := <-downtimes
+	var event DowntimeEvent
+	if err := json.Unmarshal(message.data, &event); err != nil {
+		t.Fatal(err)
+	}
+	if message.event != transitionDown || !event.StartTime.Equal(at) {
+		t.Errorf("got %s starting %v, want %s starting %v", message.event, event.StartTime, transitionDown, at)
+	}
+}
+
+func TestSeedDowntimesCountsStoredOutages(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewServer(nil, "", Options{Stats: StatsOptions{Clock: clock.NewFakeClock(start.Add(time.Hour))}})
+	entry := func(minute int, success bool) storage.LogEntry {
+		at := start.Add(time.Duration(minute) * time.Minute)
+		result := monitor.PingResult{Host: "a", Timestamp: at, Success: success}
+		if !success {
+			result.Error = "i/o timeout"
+		}
+		return storage.LogEntry{Timestamp: at, Results: []monitor.PingResult{result}}
+	}
+
+	s.SeedDowntimes([]storage.LogEntry{entry(0, true), entry(1, false), entry(2, false), entry(3, true), entry(4, false)})
+	if got := s.DowntimeEvents(); got != 2 {
+		t.Errorf("DowntimeEvents = %d, want 2 outages from the stored logs", got)
+	}
+	if state := s.transitions.debugState().(outageState); state.Active {
+		t.Error("replaying stored logs opened a live outage")
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"monitrix/internal/storage"
+)
+
+// Response formats selectable through the Accept header
+const (
+	formatJSON    = "application/json"
+	formatMsgPack = "application/msgpack"
+	formatCSV     = "text/csv"
+)
+
+// csvFlushRows is how many CSV rows are written between flushes when streaming
+const csvFlushRows = 500
+
+// negotiateFormat picks the response format from the Accept header,
+// falling back to JSON for missing or unsupported media types
+func negotiateFormat(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case formatJSON:
+			return formatJSON
+		case formatMsgPack, "application/x-msgpack":
+			return formatMsgPack
+		case formatCSV:
+			return formatCSV
+		}
+	}
+	return formatJSON
+}
+
+// writeResponse encodes v in the negotiated format. writeCSV renders the
+// CSV representation; when it is nil CSV requests fall back to JSON.
+func writeResponse(w http.ResponseWriter, r *http.Request, v any, writeCSV func(*csv.Writer) error) error {
+	w.Header().Add("Vary", "Accept")
+
+	format := negotiateFormat(r)
+	if format == formatCSV && writeCSV == nil {
+		format = formatJSON
+	}
+	w.Header().Set("Content-Type", format)
+
+	switch format {
+	case formatMsgPack:
+		enc := msgpack.NewEncoder(w)
+		enc.SetCustomStructTag("json")
+		return enc.Encode(v)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := writeCSV(cw); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return json.NewEncoder(w).Encode(v)
+	}
+}
+
+// logsCSV streams log entries as one CSV row per ping result
+func logsCSV(w http.ResponseWriter, logs []storage.LogEntry) func(*csv.Writer) error {
+	return func(cw *csv.Writer) error {
+		if err := cw.Write([]string{"timestamp", "host", "success", "latency_ms", "dns_latency_ms", "connect_latency_ms", "error"}); err != nil {
+			return err
+		}
+
+		flusher, _ := w.(http.Flusher)
+		rows := 0
+		for _, entry := range logs {
+			for _, result := range entry.Results {
+				record := []string{
+					entry.Timestamp.Format(time.RFC3339),
+					result.Host,
+					strconv.FormatBool(result.Success),
+					strconv.FormatInt(result.Latency, 10),
+					strconv.FormatInt(result.DNSLatency, 10),
+					strconv.FormatInt(result.ConnectLatency, 10),
+					result.Error,
+				}
+				if err := cw.Write(record); err != nil {
+					return err
+				}
+
+				rows++
+				if rows%csvFlushRows == 0 {
+					cw.Flush()
+					if flusher != nil {
+						flusher.Flush()
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// statsCSV renders the summary statistics as metric,value rows
+func statsCSV(stats Stats) func(*csv.Writer) error {
+	return func(cw *csv.Writer) error {
+		formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+
+		rows := [][]string{
+			{"metric", "value"},
+			{"current_status", stats.CurrentStatus},
+			{"monitoring_stalled", strconv.FormatBool(stats.MonitoringStalled)},
+			{"total_checks", strconv.Itoa(stats.TotalChecks)},
+			{"online_checks", strconv.Itoa(stats.OnlineChecks)},
+			{"offline_checks", strconv.Itoa(stats.OfflineChecks)},
+			{"paused_checks", strconv.Itoa(stats.PausedChecks)},
+			{"uptime_percentage", formatFloat(stats.UptimePercentage)},
+			{"probe_success_percentage", formatFloat(stats.ProbeSuccessPercentage)},
+			{"total_downtime_hours", formatFloat(stats.TotalDowntimeHours)},
+			{"downtime_events", strconv.Itoa(len(stats.DowntimeEvents))},
+		}
+		return cw.WriteAll(rows)
+	}
+}
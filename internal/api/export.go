@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"monitrix/internal/storage"
+)
+
+// exportColumns is the header row of /api/export
+var exportColumns = []string{"timestamp", "host", "success", "latency_ms", "error"}
+
+// handleExport downloads log entries as a CSV attachment with one row per
+// ping result, for spreadsheets. It takes the same start and end as
+// /api/logs; local logs are streamed without loading the range.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	startTime, endTime, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	export := exportWriter{w: w, cw: csv.NewWriter(w)}
+	if scanner, ok := s.store.(storage.Scanner); ok && len(s.peers) == 0 {
+		// Headers go out with the first row, so a read error before then
+		// can still be reported; after that the download is just cut short
+		if err := scanner.ScanLogs(startTime, endTime, func(entry storage.LogEntry) {
+			export.start(startTime, endTime)
+			export.write(entry)
+		}); err != nil {
+			if !export.started {
+				http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+				return
+			}
+			fmt.Printf("Warning: export interrupted: %v\n", err)
+		}
+	} else {
+		logs, err := s.readLogs(w, startTime, endTime)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+			return
+		}
+		export.start(startTime, endTime)
+		for _, entry := range logs {
+			export.write(entry)
+		}
+	}
+
+	export.start(startTime, endTime)
+	export.cw.Flush()
+}
+
+// exportWriter writes CSV rows, flushing every csvFlushRows so the client
+// receives the file as it is read
+type exportWriter struct {
+	w       http.ResponseWriter
+	cw      *csv.Writer
+	started bool
+	rows    int
+	err     error
+}
+
+// start sends the headers and column row once
+func (e *exportWriter) start(startTime, endTime *time.Time) {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.w.Header().Set("Content-Type", formatCSV)
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename(startTime, endTime)))
+	e.err = e.cw.Write(exportColumns)
+}
+
+// write adds one row per ping result in entry, stopping quietly once the
+// client has gone away
+func (e *exportWriter) write(entry storage.LogEntry) {
+	if e.err != nil {
+		return
+	}
+	for _, result := range entry.Results {
+		record := []string{
+			entry.Timestamp.Format(time.RFC3339),
+			result.Host,
+			strconv.FormatBool(result.Success),
+			strconv.FormatInt(result.Latency, 10),
+			result.Error,
+		}
+		if e.err = e.cw.Write(record); e.err != nil {
+			return
+		}
+
+		e.rows++
+		if e.rows%csvFlushRows == 0 {
+			e.cw.Flush()
+			if e.err = e.cw.Error(); e.err != nil {
+				return
+			}
+			if flusher, ok := e.w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// exportFilename names the download after its date range, e.g.
+// monitrix_2025-01-01_to_2025-01-31.csv. An open end is "start" or "now".
+func exportFilename(startTime, endTime *time.Time) string {
+	from, to := "start", "now"
+	if startTime != nil {
+		from = startTime.Local().Format("2006-01-02")
+	}
+	if endTime != nil {
+		to = endTime.Local().Format("2006-01-02")
+	}
+	return fmt.Sprintf("monitrix_%s_to_%s.csv", from, to)
+}
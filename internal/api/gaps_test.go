@@ -0,0 +1,95 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// cycleLog builds one log entry per offset from base, online where up is
+// true and with every host failing otherwise
+func cycleLog(base time.Time, offsets []time.Duration, up []bool) []storage.LogEntry {
+	logs := make([]storage.LogEntry, len(offsets))
+	for i, offset := range offsets {
+		at := base.Add(offset)
+		results := []monitor.PingResult{
+			{Host: "a", Timestamp: at, Success: up[i]},
+			{Host: "b", Timestamp: at, Success: up[i]},
+		}
+		for j := range results {
+			if !up[i] {
+				results[j].Error = "i/o timeout"
+			}
+		}
+		logs[i] = storage.LogEntry{Timestamp: at, Results: results}
+	}
+	return logs
+}
+
+func TestMonitoringGaps(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	minutes := func(ms ...int) []time.Duration {
+		offsets := make([]time.Duration, len(ms))
+		for i, m := range ms {
+			offsets[i] = time.Duration(m) * time.Minute
+		}
+		return offsets
+	}
+	// A restart left no checks between minutes 2 and 62
+	restart := minutes(0, 1, 2, 62, 63)
+
+	tests := []struct {
+		name     string
+		bridge   bool
+		up       []bool
+		events   int
+		spansGap bool
+		seconds  int64 // of the first event
+	}{
+		{"outage across restart bridged", true, []bool{true, false, false, false, true}, 1, true, 62 * 60},
+		{"outage across restart split", false, []bool{true, false, false, false, true}, 2, false, 60},
+		{"recovered across restart", true, []bool{true, false, false, true, true}, 1, false, 60},
+		{"outage starting after restart", true, []bool{true, true, true, false, true}, 1, false, 60},
+		{"up on both sides", true, []bool{true, true, true, true, true}, 0, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := StatsOptions{
+				GapThreshold: 5 * time.Minute,
+				BridgeGaps:   test.bridge,
+			}
+			stats := calculateStats(cycleLog(base, restart, test.up), opts)
+
+			if stats.MonitoringGaps != 1 {
+				t.Errorf("monitoring gaps = %d, want 1", stats.MonitoringGaps)
+			}
+			if len(stats.DowntimeEvents) != test.events {
+				t.Fatalf("got %d downtime events %+v, want %d", len(stats.DowntimeEvents), stats.DowntimeEvents, test.events)
+			}
+			if test.events == 0 {
+				return
+			}
+			first := stats.DowntimeEvents[0]
+			if first.SpansGap != test.spansGap {
+				t.Errorf("spans gap = %v, want %v", first.SpansGap, test.spansGap)
+			}
+			if first.Duration != test.seconds {
+				t.Errorf("duration = %ds, want %ds", first.Duration, test.seconds)
+			}
+		})
+	}
+}
+
+func TestGapThresholdDisabled(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{0, time.Minute, time.Hour, time.Hour + time.Minute}
+	stats := calculateStats(cycleLog(base, offsets, []bool{true, false, false, true}), StatsOptions{})
+	if stats.MonitoringGaps != 0 {
+		t.Errorf("monitoring gaps = %d with detection disabled, want 0", stats.MonitoringGaps)
+	}
+	if len(stats.DowntimeEvents) != 1 || stats.DowntimeEvents[0].Duration != 60*60 {
+		t.Errorf("got %+v, want one outage running until the recovery", stats.DowntimeEvents)
+	}
+}
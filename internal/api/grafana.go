@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"monitrix/internal/storage"
+)
+
+// Grafana targets. Per-host targets are "<metric>:<host>".
+const (
+	grafanaUptime  = "uptime"  // percentage of online cycles
+	grafanaLatency = "latency" // average trusted latency in ms
+)
+
+// grafanaSearchWindow is how far back /grafana/search looks for host names
+const grafanaSearchWindow = 24 * time.Hour
+
+// grafanaRange is the time range of a SimpleJSON request
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// grafanaQuery is the body of a SimpleJSON /query request
+type grafanaQuery struct {
+	Range         grafanaRange `json:"range"`
+	IntervalMs    int64        `json:"intervalMs"`
+	MaxDataPoints int          `json:"maxDataPoints"`
+	Targets       []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// grafanaSeries is one timeseries in a /query response; each datapoint is [value, unix ms]
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// grafanaAnnotation is one downtime event in an /annotations response
+type grafanaAnnotation struct {
+	Annotation json.RawMessage `json:"annotation"`
+	Time       int64           `json:"time"`
+	TimeEnd    int64           `json:"timeEnd"`
+	IsRegion   bool            `json:"isRegion"`
+	Title      string          `json:"title"`
+	Text       string          `json:"text"`
+	Tags       []string        `json:"tags"`
+}
+
+// handleGrafanaRoot answers the datasource's connection test
+func (s *Server) handleGrafanaRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/grafana/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+// handleGrafanaSearch lists the available targets: the overall metrics plus
+// one per host seen recently, filtered by the request's target substring
+func (s *Server) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		Target string `json:"target"`
+	}
+	if r.Method == http.MethodPost {
+		json.NewDecoder(r.Body).Decode(&req) // an empty body lists everything
+	}
+
+	since := time.Now().Add(-grafanaSearchWindow)
+	logs, err := s.readLogs(w, &since, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range logs {
+		for _, result := range entry.Results {
+			seen[result.Host] = true
+		}
+	}
+	hosts := make([]string, 0, len(seen))
+	for host := range seen {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	targets := []string{}
+	for _, metric := range []string{grafanaUptime, grafanaLatency} {
+		candidates := []string{metric}
+		for _, host := range hosts {
+			candidates = append(candidates, metric+":"+host)
+		}
+		for _, target := range candidates {
+			if strings.Contains(target, req.Target) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(targets)
+}
+
+// handleGrafanaQuery returns each requested target as a timeseries, one
+// datapoint per interval computed with the same statistics as /api/stats.
+// Intervals without data are left out rather than reported as zero.
+func (s *Server) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid query body", http.StatusBadRequest)
+		return
+	}
+	if !req.Range.To.After(req.Range.From) {
+		http.Error(w, "Invalid query range", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := s.readLogs(w, &req.Range.From, &req.Range.To)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	buckets := bucketLogs(logs, req.Range, grafanaInterval(req))
+	series := make([]grafanaSeries, 0, len(req.Targets))
+	for _, target := range req.Targets {
+		metric, _, _ := strings.Cut(target.Target, ":")
+		if metric != grafanaUptime && metric != grafanaLatency {
+			http.Error(w, fmt.Sprintf("Unknown target %q", target.Target), http.StatusBadRequest)
+			return
+		}
+		series = append(series, grafanaSeries{Target: target.Target, Datapoints: [][2]float64{}})
+	}
+
+	for _, bucket := range buckets {
+		stats := calculateStats(bucket.logs, s.statsOpts)
+		ts := float64(bucket.start.UnixMilli())
+		for i, target := range req.Targets {
+			metric, host, _ := strings.Cut(target.Target, ":")
+			if value, ok := grafanaValue(metric, host, bucket.logs, stats); ok {
+				series[i].Datapoints = append(series[i].Datapoints, [2]float64{value, ts})
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(series)
+}
+
+// handleGrafanaAnnotations returns the downtime events in range as region annotations
+func (s *Server) handleGrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		Range      grafanaRange    `json:"range"`
+		Annotation json.RawMessage `json:"annotation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid annotation query body", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := s.readLogs(w, &req.Range.From, &req.Range.To)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Every event in range is annotated, regardless of the list bounds
+	opts := s.statsOpts
+	opts.MinDowntime = 0
+	opts.MaxDowntimeEvents = 0
+	stats := calculateStats(logs, opts)
+
+	annotations := make([]grafanaAnnotation, 0, len(stats.DowntimeEvents))
+	for _, event := range stats.DowntimeEvents {
+		end := req.Range.To
+		if event.EndTime != nil {
+			end = *event.EndTime
+		}
+		annotations = append(annotations, grafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       event.StartTime.UnixMilli(),
+			TimeEnd:    end.UnixMilli(),
+			IsRegion:   true,
+			Title:      fmt.Sprintf("Internet offline (%s)", event.Severity),
+			Text:       "Failed hosts: " + strings.Join(event.FailedHosts, ", "),
+			Tags:       []string{"downtime", string(event.Severity)},
+		})
+	}
+	json.NewEncoder(w).Encode(annotations)
+}
+
+// grafanaInterval returns the bucket width for a query: the requested
+// interval, widened so the range yields at most maxDataPoints buckets
+func grafanaInterval(req grafanaQuery) time.Duration {
+	interval := time.Duration(req.IntervalMs) * time.Millisecond
+	if req.MaxDataPoints > 0 {
+		minInterval := req.Range.To.Sub(req.Range.From) / time.Duration(req.MaxDataPoints)
+		interval = max(interval, minInterval)
+	}
+	return max(interval, time.Second)
+}
+
+// logBucket holds the entries of one query interval
+type logBucket struct {
+	start time.Time
+	logs  []storage.LogEntry
+}
+
+// bucketLogs groups chronologically ordered entries into fixed intervals
+// starting at the range start, omitting empty intervals
+func bucketLogs(logs []storage.LogEntry, rng grafanaRange, interval time.Duration) []logBucket {
+	var buckets []logBucket
+	for _, entry := range logs {
+		start := rng.From.Add(entry.Timestamp.Sub(rng.From).Truncate(interval))
+		if n := len(buckets); n > 0 && buckets[n-1].start.Equal(start) {
+			buckets[n-1].logs = append(buckets[n-1].logs, entry)
+			continue
+		}
+		buckets = append(buckets, logBucket{start: start, logs: []storage.LogEntry{entry}})
+	}
+	return buckets
+}
+
+// grafanaValue computes one target's value for a bucket; ok is false when
+// the bucket has no data for it
+func grafanaValue(metric, host string, logs []storage.LogEntry, stats Stats) (value float64, ok bool) {
+	if host == "" {
+		if metric == grafanaUptime {
+			return stats.UptimePercentage, stats.OnlineChecks+stats.OfflineChecks > 0
+		}
+		// Overall latency averages the hosts' samples
+		var sum float64
+		var samples int
+		for _, hostStats := range stats.PerHost {
+			sum += hostStats.AverageLatency * float64(hostStats.LatencySamples)
+			samples += hostStats.LatencySamples
+		}
+		if samples == 0 {
+			return 0, false
+		}
+		return sum / float64(samples), true
+	}
+
+	if metric == grafanaLatency {
+		hostStats, ok := stats.PerHost[host]
+		if !ok || hostStats.LatencySamples == 0 {
+			return 0, false
+		}
+		return hostStats.AverageLatency, true
+	}
+
+	// A host's uptime is the share of its checks that succeeded
+	var sent, received int
+	for _, entry := range logs {
+		for _, result := range entry.Results {
+			if result.Host == host {
+				s, r := checkCounts(result)
+				sent += s
+				received += r
+			}
+		}
+	}
+	if sent == 0 {
+		return 0, false
+	}
+	return float64(received) / float64(sent) * 100, true
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// staleIntervals is how many check intervals may pass without results
+// before /healthz reports monitoring as stalled
+const staleIntervals = 2
+
+// Health is the /healthz response
+type Health struct {
+	Status    string     `json:"status"`     // "ok" or "stalled"
+	LastCheck *time.Time `json:"last_check"` // null until the first results arrive
+}
+
+// handleHealth reports whether monitoring is making progress, for load
+// balancers and container probes: 200 while results keep arriving and 503
+// once none have for staleIntervals check intervals. Before the first
+// results the server's start time stands in for the last check.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := Health{Status: "ok"}
+	since := s.started
+	if last := s.lastCheck.Load(); last != 0 {
+		lastCheck := time.Unix(0, last)
+		health.LastCheck = &lastCheck
+		since = lastCheck
+	}
+
+	status := http.StatusOK
+	if s.interval > 0 && s.statsOpts.now().Sub(since) > staleIntervals*s.interval {
+		health.Status = "stalled"
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(health)
+}
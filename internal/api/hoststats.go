@@ -0,0 +1,121 @@
+package api
+
+import (
+	"monitrix/internal/monitor"
+)
+
+// DefaultEWMAAlpha is the smoothing factor used when none is configured
+const DefaultEWMAAlpha = 0.3
+
+// HostStats summarises a single host's results over the requested range
+type HostStats struct {
+	TotalChecks      int     `json:"total_checks"` // probed checks, excluding paused ones
+	SuccessfulChecks int     `json:"successful_checks"`
+	UptimePercentage float64 `json:"uptime_percentage"`
+
+	LatencySamples  int     `json:"latency_samples"`
+	AverageLatency  float64 `json:"average_latency_ms"`
+	EWMALatency     float64 `json:"ewma_latency_ms"`            // exponentially-weighted; favours recent samples
+	CappedLatencies int     `json:"capped_latencies,omitempty"` // samples counted at the latency cap
+	RefusedChecks   int     `json:"refused_checks,omitempty"`   // failures where the host refused the connection
+
+	// Nearest-rank percentiles of the raw trusted latencies; roll-up
+	// records only keep an average, so they aren't included
+	LatencyP50 float64 `json:"latency_p50_ms"`
+	LatencyP95 float64 `json:"latency_p95_ms"`
+	LatencyP99 float64 `json:"latency_p99_ms"`
+
+	// LatencyJitter is the mean absolute difference between consecutive
+	// trusted latencies, skipping failures in between; zero with fewer than two
+	LatencyJitter float64 `json:"latency_jitter_ms"`
+
+	// PacketLossPercent is the share of probes lost across checks that sent
+	// several; null when none did
+	PacketLossPercent *float64 `json:"packet_loss_percent,omitempty"`
+
+	// The host's run of successful or failed probes ending at its latest result
+	CurrentSuccessStreak *Streak `json:"current_success_streak,omitempty"`
+	CurrentFailureStreak *Streak `json:"current_failure_streak,omitempty"`
+}
+
+// hostAccumulator builds HostStats in a single pass over chronologically ordered results
+type hostAccumulator struct {
+	stats      HostStats
+	latencySum float64
+	latencies  latencyDistribution
+	jitter     jitterTracker
+	streak     streakTracker
+
+	probesSent, probesReceived int // from multi-probe checks
+}
+
+// add folds one result into the accumulator
+func (a *hostAccumulator) add(result monitor.PingResult, opts StatsOptions) {
+	sent, received := checkCounts(result)
+	mixed := received > 0 && received < sent
+	a.stats.TotalChecks += sent
+	a.stats.SuccessfulChecks += received
+	if result.Code() == monitor.CodeRefused {
+		a.stats.RefusedChecks++
+	}
+	a.probesSent += result.ProbesSent
+	a.probesReceived += result.ProbesReceived
+	if received > 0 {
+		a.streak.add(true, received, result.Timestamp, result.Timestamp, mixed)
+	} else {
+		a.streak.add(false, sent, result.Timestamp, result.Timestamp, mixed)
+	}
+
+	// Roll-ups carry an average of their trusted samples, weighted here by
+	// how many samples it covers
+	samples := 1
+	if result.Aggregate != nil {
+		samples = result.Aggregate.LatencySamples
+		if samples == 0 {
+			return
+		}
+	} else if !result.LatencyTrusted() {
+		return
+	}
+	if result.Aggregate == nil {
+		a.latencies.add(result.Latency)
+		a.jitter.add(result.Latency)
+	}
+
+	latency := float64(result.Latency)
+	if limit := float64(opts.LatencyCap.Milliseconds()); limit > 0 && latency > limit {
+		latency = limit
+		a.stats.CappedLatencies += samples
+	}
+	a.latencySum += latency * float64(samples)
+	a.stats.LatencySamples += samples
+
+	if a.stats.LatencySamples == samples {
+		a.stats.EWMALatency = latency
+	} else {
+		alpha := opts.EWMAAlpha
+		if alpha <= 0 || alpha > 1 {
+			alpha = DefaultEWMAAlpha
+		}
+		a.stats.EWMALatency = alpha*latency + (1-alpha)*a.stats.EWMALatency
+	}
+}
+
+// finish returns the completed statistics
+func (a *hostAccumulator) finish() HostStats {
+	stats := a.stats
+	stats.CurrentSuccessStreak, stats.CurrentFailureStreak = a.streak.current()
+	if stats.TotalChecks > 0 {
+		stats.UptimePercentage = float64(stats.SuccessfulChecks) / float64(stats.TotalChecks) * 100
+	}
+	if stats.LatencySamples > 0 {
+		stats.AverageLatency = a.latencySum / float64(stats.LatencySamples)
+	}
+	stats.LatencyP50, stats.LatencyP95, stats.LatencyP99 = a.latencies.percentiles()
+	stats.LatencyJitter = a.jitter.mean()
+	if a.probesSent > 0 {
+		loss := float64(a.probesSent-a.probesReceived) / float64(a.probesSent) * 100
+		stats.PacketLossPercent = &loss
+	}
+	return stats
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"monitrix/internal/monitor"
+)
+
+// maxIngestBytes bounds the size of a single ingest request body
+const maxIngestBytes = 10 << 20
+
+// handleIngest stores results pushed by another Monitrix instance
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.ingest == nil {
+		http.Error(w, "Ingest is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var results []monitor.PingResult
+	body := http.MaxBytesReader(w, r.Body, maxIngestBytes)
+	if err := json.NewDecoder(body).Decode(&results); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid results payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(results) == 0 {
+		http.Error(w, "Results payload is empty", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.ingest.Save(results); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to store results: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
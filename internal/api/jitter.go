@@ -0,0 +1,42 @@
+package api
+
+// jitterTracker measures latency variation as the mean absolute difference
+// between consecutive samples, which must arrive in chronological order
+type jitterTracker struct {
+	last    int64
+	started bool
+	sum     int64
+	pairs   int
+}
+
+// add records the next latency sample
+func (j *jitterTracker) add(latency int64) {
+	if j.started {
+		diff := latency - j.last
+		if diff < 0 {
+			diff = -diff
+		}
+		j.sum += diff
+		j.pairs++
+	}
+	j.last, j.started = latency, true
+}
+
+// mean returns the jitter, zero with fewer than two samples
+func (j *jitterTracker) mean() float64 {
+	if j.pairs == 0 {
+		return 0
+	}
+	return float64(j.sum) / float64(j.pairs)
+}
+
+// pooledJitter is the jitter across every host's consecutive samples, so
+// hosts with more samples weigh more
+func pooledJitter(hosts map[string]*hostAccumulator) float64 {
+	var pooled jitterTracker
+	for _, acc := range hosts {
+		pooled.sum += acc.jitter.sum
+		pooled.pairs += acc.jitter.pairs
+	}
+	return pooled.mean()
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Bind retry backoff bounds
+const (
+	bindBackoffMin = 250 * time.Millisecond
+	bindBackoffMax = 5 * time.Second
+)
+
+// listen binds addr, retrying with exponential backoff for up to s.bindRetry
+// so a restart doesn't fail while the previous instance releases the port.
+// Go's listeners already set SO_REUSEADDR on Unix, so sockets lingering in
+// TIME_WAIT don't block the bind; only a live listener does.
+func (s *Server) listen(addr string) (net.Listener, error) {
+	deadline := time.Now().Add(s.bindRetry)
+	backoff := bindBackoffMin
+	for {
+		ln, err := net.Listen("tcp", addr)
+		if err == nil {
+			return ln, nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return nil, fmt.Errorf("gave up after %v: %w", s.bindRetry, err)
+		}
+
+		fmt.Printf("Web server bind failed (%v), retrying in %v\n", err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, bindBackoffMax)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"monitrix/internal/storage"
+)
+
+// Paging limits for /api/logs
+const (
+	defaultLogLimit = 1000
+	maxLogLimit     = 10000
+)
+
+// LogPage is one page of /api/logs, returned instead of the bare array
+// when any of limit, offset or order is given
+type LogPage struct {
+	Entries []storage.LogEntry `json:"entries"`
+	Total   int                `json:"total"`    // entries in the whole range
+	HasMore bool               `json:"has_more"` // further pages follow this one
+}
+
+// logPaging is the paging requested for /api/logs
+type logPaging struct {
+	limit, offset int
+	newestFirst   bool
+}
+
+// parseLogPaging reads limit, offset and order from the query. ok is false
+// when none is present, meaning the caller wants the bare array.
+func parseLogPaging(query url.Values) (paging logPaging, ok bool, err error) {
+	if !query.Has("limit") && !query.Has("offset") && !query.Has("order") {
+		return logPaging{}, false, nil
+	}
+	paging = logPaging{limit: defaultLogLimit, newestFirst: true}
+
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return paging, true, fmt.Errorf("invalid limit %q: expected a positive integer", s)
+		}
+		paging.limit = min(limit, maxLogLimit)
+	}
+	if s := query.Get("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return paging, true, fmt.Errorf("invalid offset %q: expected a non-negative integer", s)
+		}
+		paging.offset = offset
+	}
+	switch order := query.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		paging.newestFirst = false
+	default:
+		return paging, true, fmt.Errorf("invalid order %q: expected asc or desc", order)
+	}
+	return paging, true, nil
+}
+
+// page cuts the requested page from logs, which are oldest first
+func (p logPaging) page(logs []storage.LogEntry) LogPage {
+	if p.newestFirst {
+		logs = slices.Clone(logs)
+		slices.Reverse(logs)
+	}
+	start := min(p.offset, len(logs))
+	end := min(start+p.limit, len(logs))
+	return LogPage{
+		Entries: logs[start:end],
+		Total:   len(logs),
+		HasMore: end < len(logs),
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"monitrix/internal/storage"
+)
+
+// readLogs reads local logs and merges in logs from every peer. Unreachable
+// peers are skipped and reported through the X-Monitrix-Peer-Errors header
+// rather than failing the request.
+func (s *Server) readLogs(w http.ResponseWriter, startTime, endTime *time.Time) ([]storage.LogEntry, error) {
+	logs, err := s.store.ReadLogs(startTime, endTime)
+	if err != nil || len(s.peers) == 0 {
+		return logs, err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var peerErrors []string
+
+	for _, peer := range s.peers {
+		wg.Add(1)
+		go func(peer storage.Reader) {
+			defer wg.Done()
+
+			entries, err := peer.ReadLogs(startTime, endTime)
+			for i := range entries {
+				entries[i].Source = fmt.Sprint(peer)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Printf("Warning: failed to read logs from peer %v: %v\n", peer, err)
+				peerErrors = append(peerErrors, fmt.Sprint(peer))
+				return
+			}
+			logs = append(logs, entries...)
+		}(peer)
+	}
+	wg.Wait()
+
+	if len(peerErrors) > 0 {
+		sort.Strings(peerErrors)
+		w.Header().Set("X-Monitrix-Peer-Errors", strings.Join(peerErrors, ", "))
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+	return logs, nil
+}
+
+// handleLogsStream streams this instance's own log entries as JSON Lines.
+// Peers are deliberately not included so federated instances can't loop.
+func (s *Server) handleLogsStream(w http.ResponseWriter, r *http.Request) {
+	startTime, endTime, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	logs, err := s.store.ReadLogs(startTime, endTime)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for i, entry := range logs {
+		if err := encoder.Encode(entry); err != nil {
+			return
+		}
+		if flusher != nil && (i+1)%csvFlushRows == 0 {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"math"
+	"slices"
+)
+
+// latencyDistribution counts latencies by millisecond, so percentiles over
+// long ranges don't need every sample in memory
+type latencyDistribution struct {
+	counts map[int64]int
+	total  int
+}
+
+// add records one latency sample
+func (d *latencyDistribution) add(latency int64) {
+	if d.counts == nil {
+		d.counts = make(map[int64]int)
+	}
+	d.counts[latency]++
+	d.total++
+}
+
+// percentiles returns the 50th, 95th and 99th percentiles by the
+// nearest-rank method: the smallest sample with at least p% of samples at
+// or below it. All are zero without samples.
+func (d *latencyDistribution) percentiles() (p50, p95, p99 float64) {
+	if d.total == 0 {
+		return 0, 0, 0
+	}
+	latencies := make([]int64, 0, len(d.counts))
+	for latency := range d.counts {
+		latencies = append(latencies, latency)
+	}
+	slices.Sort(latencies)
+
+	ranks := []int{rank(50, d.total), rank(95, d.total), rank(99, d.total)}
+	values := make([]float64, len(ranks))
+	seen, next := 0, 0
+	for _, latency := range latencies {
+		seen += d.counts[latency]
+		for next < len(ranks) && seen >= ranks[next] {
+			values[next] = float64(latency)
+			next++
+		}
+	}
+	return values[0], values[1], values[2]
+}
+
+// rank is the 1-based nearest rank of percentile p among n samples
+func rank(p float64, n int) int {
+	return max(1, int(math.Ceil(p/100*float64(n))))
+}
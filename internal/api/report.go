@@ -0,0 +1,120 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// DefaultSLAObjective is the uptime target, in percent, for hosts without
+// their own objective
+const DefaultSLAObjective = 99.9
+
+// SLAObjectives are the uptime targets a report is measured against
+type SLAObjectives struct {
+	Default float64            // percent; zero means DefaultSLAObjective
+	Hosts   map[string]float64 // per-host overrides
+}
+
+// objectiveFor returns the uptime target for host, or the overall target
+// when host is empty
+func (o SLAObjectives) objectiveFor(host string) float64 {
+	if objective, ok := o.Hosts[host]; ok && host != "" {
+		return objective
+	}
+	if o.Default > 0 {
+		return o.Default
+	}
+	return DefaultSLAObjective
+}
+
+// SLAResult is the availability of one host, or of the internet as a whole
+// when Host is empty, over a report period
+type SLAResult struct {
+	Host            string          `json:"host,omitempty"`
+	UptimePercent   float64         `json:"uptime_percent"`
+	Objective       float64         `json:"objective"`
+	Met             bool            `json:"met"` // false when there were no checks
+	CheckCount      int             `json:"check_count"`
+	DowntimeSeconds int64           `json:"downtime_seconds"`
+	Outages         []DowntimeEvent `json:"outages"`
+}
+
+// Report is an SLA report over [Start, End)
+type Report struct {
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"`
+	Overall SLAResult   `json:"overall"`
+	Hosts   []SLAResult `json:"hosts"`
+}
+
+// BuildReport computes SLA attainment from logs covering [start, end). The
+// overall result follows opts.Up; each host is judged on its own results.
+// Outages still open at the end of the period are cut off there, and
+// outages are listed oldest first.
+func BuildReport(logs []storage.LogEntry, opts StatsOptions, objectives SLAObjectives, start, end time.Time) Report {
+	// Every outage counts towards attainment, regardless of list bounds
+	opts.MinDowntime = 0
+	opts.MaxDowntimeEvents = 0
+
+	report := Report{
+		Start:   start,
+		End:     end,
+		Overall: slaResult(logs, opts, objectives, "", end),
+	}
+
+	hostOpts := opts
+	hostOpts.Up.Rule = monitor.UpRuleAll
+	for _, host := range reportHosts(logs) {
+		report.Hosts = append(report.Hosts, slaResult(hostLogs(logs, host), hostOpts, objectives, host, end))
+	}
+	return report
+}
+
+// slaResult measures logs against the objective for host
+func slaResult(logs []storage.LogEntry, opts StatsOptions, objectives SLAObjectives, host string, end time.Time) SLAResult {
+	stats := calculateStats(logs, opts)
+	result := SLAResult{
+		Host:          host,
+		UptimePercent: stats.UptimePercentage,
+		Objective:     objectives.objectiveFor(host),
+		CheckCount:    stats.OnlineChecks + stats.OfflineChecks,
+		Outages:       make([]DowntimeEvent, 0, len(stats.DowntimeEvents)),
+	}
+	result.Met = result.CheckCount > 0 && result.UptimePercent >= result.Objective
+
+	for _, event := range stats.DowntimeEvents {
+		if event.EndTime == nil || event.EndTime.After(end) {
+			cut := end
+			event.EndTime = &cut
+			event.IsOngoing = false
+			event.Duration = int64(cut.Sub(event.StartTime).Seconds())
+			event.Severity = opts.Severity.classify(cut.Sub(event.StartTime))
+		}
+		result.DowntimeSeconds += event.Duration
+		result.Outages = append(result.Outages, event)
+	}
+	// Reports read in date order, unlike the most-recent-first stats
+	sort.Slice(result.Outages, func(i, j int) bool {
+		return result.Outages[i].StartTime.Before(result.Outages[j].StartTime)
+	})
+	return result
+}
+
+// reportHosts returns every host seen in logs, sorted
+func reportHosts(logs []storage.LogEntry) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, entry := range logs {
+		for _, result := range entry.Results {
+			if !seen[result.Host] {
+				seen[result.Host] = true
+				hosts = append(hosts, result.Host)
+			}
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
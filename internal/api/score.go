@@ -0,0 +1,97 @@
+package api
+
+// Latency bounds for the quality score: averages at or below goodLatencyMs
+// score 100, at or above badLatencyMs score 0, linear in between
+const (
+	goodLatencyMs = 50.0
+	badLatencyMs  = 500.0
+)
+
+// ScoreWeights sets the relative importance of each quality component.
+// Weights are normalised, so only their ratios matter.
+type ScoreWeights struct {
+	Uptime  float64 `json:"uptime"`
+	Latency float64 `json:"latency"`
+	Loss    float64 `json:"loss"`
+}
+
+// DefaultScoreWeights favours uptime, then latency, then probe loss
+var DefaultScoreWeights = ScoreWeights{Uptime: 0.5, Latency: 0.3, Loss: 0.2}
+
+// QualityComponents holds each component of the quality score, scored 0–100
+type QualityComponents struct {
+	Uptime  float64 `json:"uptime"`
+	Latency float64 `json:"latency"`
+	Loss    float64 `json:"loss"`
+}
+
+// Quality is a composite 0–100 connection quality score
+type Quality struct {
+	Score      float64           `json:"score"`
+	Rating     string            `json:"rating"`
+	Components QualityComponents `json:"components"`
+}
+
+// calculateQuality combines uptime, latency and loss into a single score
+func calculateQuality(stats Stats, weights ScoreWeights) *Quality {
+	if stats.TotalChecks == 0 {
+		return nil
+	}
+	if weights.Uptime+weights.Latency+weights.Loss <= 0 {
+		weights = DefaultScoreWeights
+	}
+
+	// Average latency across hosts, weighted by how many samples each contributed
+	var latencySum float64
+	var samples int
+	for _, host := range stats.PerHost {
+		latencySum += host.AverageLatency * float64(host.LatencySamples)
+		samples += host.LatencySamples
+	}
+
+	latencyScore := 0.0
+	if samples > 0 {
+		avg := latencySum / float64(samples)
+		switch {
+		case avg <= goodLatencyMs:
+			latencyScore = 100
+		case avg >= badLatencyMs:
+			latencyScore = 0
+		default:
+			latencyScore = 100 * (badLatencyMs - avg) / (badLatencyMs - goodLatencyMs)
+		}
+	}
+
+	components := QualityComponents{
+		Uptime:  stats.UptimePercentage,
+		Latency: latencyScore,
+		Loss:    stats.ProbeSuccessPercentage,
+	}
+
+	total := weights.Uptime + weights.Latency + weights.Loss
+	score := (weights.Uptime*components.Uptime +
+		weights.Latency*components.Latency +
+		weights.Loss*components.Loss) / total
+
+	return &Quality{
+		Score:      score,
+		Rating:     qualityRating(score),
+		Components: components,
+	}
+}
+
+// qualityRating maps a score to a human-readable label
+func qualityRating(score float64) string {
+	switch {
+	case score >= 95:
+		return "Excellent"
+	case score >= 85:
+		return "Good"
+	case score >= 70:
+		return "Fair"
+	case score >= 50:
+		return "Poor"
+	default:
+		return "Bad"
+	}
+}
@@ -1,83 +1,253 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	_ "embed"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"monitrix/internal/alert"
+	"monitrix/internal/monitor"
 	"monitrix/internal/storage"
 )
 
+// statusPage is a self-contained dashboard used when web/index.html is missing
+//
+//go:embed static/status.html
+var statusPage []byte
+
 // Server handles HTTP API requests
 type Server struct {
-	dataDir string
-	webDir  string
+	store     storage.Storage
+	webDir    string
+	authToken string
+	statsOpts StatsOptions
+	ingest    storage.Sink
+	metrics   http.Handler
+	live      func() monitor.State
+	peers     []storage.Reader
+	alerts    alert.Notifier
+
+	compressMin int
+	bindRetry   time.Duration
+	corsOrigins []string
+
+	// interval is the expected time between results; /healthz compares it
+	// with lastCheck, in Unix nanoseconds
+	interval  time.Duration
+	started   time.Time
+	lastCheck atomic.Int64
+
+	debugMu      sync.Mutex
+	debugSources map[string]func() any
+
+	httpServer *http.Server
+
+	transitions    *transitionWatcher
+	downtimeStream broadcaster
+	resultStream   broadcaster
+	downtimes      atomic.Int64
+}
+
+// Options holds optional server settings
+type Options struct {
+	AuthToken string // bearer token for protected endpoints; empty disables them
+	Stats     StatsOptions
+	Ingest    storage.Sink         // destination for /api/ingest; nil disables ingest
+	Metrics   http.Handler         // Prometheus exposition handler served at /metrics
+	Live      func() monitor.State // in-memory monitor state served at /api/status
+	Peers     []storage.Reader     // remote instances whose logs are merged into queries
+	Alerts    alert.Notifier       // told when an outage starts and ends; nil disables alerts
+
+	// CompressMinBytes is the smallest response gzip-compressed on endpoints
+	// that opt in; zero disables compression
+	CompressMinBytes int
+
+	// BindRetry is how long Start keeps retrying a failed bind; zero gives up
+	// on the first failure
+	BindRetry time.Duration
+
+	// CORSOrigins are the origins allowed to call the API from a browser;
+	// "*" allows any. Nil allows any origin unless AuthToken is set, in
+	// which case only same-origin requests work.
+	CORSOrigins []string
+
+	// Interval is the expected time between checks. /healthz reports
+	// monitoring as stalled when no results arrive for two intervals; zero
+	// leaves it always healthy.
+	Interval time.Duration
 }
 
-// NewServer creates a new API server
-func NewServer(dataDir, webDir string) *Server {
-	return &Server{
-		dataDir: dataDir,
-		webDir:  webDir,
+// NewServer creates a new API server serving the logs in store
+func NewServer(store storage.Storage, webDir string, opts Options) *Server {
+	corsOrigins := opts.CORSOrigins
+	if corsOrigins == nil && opts.AuthToken == "" {
+		corsOrigins = []string{"*"}
 	}
+	// Cancelling the base context on shutdown ends streaming responses,
+	// which would otherwise keep Shutdown waiting until its deadline
+	baseCtx, cancel := context.WithCancel(context.Background())
+	httpServer := &http.Server{
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+	httpServer.RegisterOnShutdown(cancel)
+
+	s := &Server{
+		httpServer:   httpServer,
+		store:        store,
+		webDir:       webDir,
+		authToken:    opts.AuthToken,
+		statsOpts:    opts.Stats,
+		ingest:       opts.Ingest,
+		metrics:      opts.Metrics,
+		live:         opts.Live,
+		peers:        opts.Peers,
+		alerts:       opts.Alerts,
+		compressMin:  opts.CompressMinBytes,
+		bindRetry:    opts.BindRetry,
+		corsOrigins:  corsOrigins,
+		interval:     opts.Interval,
+		started:      opts.Stats.now(),
+		debugSources: make(map[string]func() any),
+		transitions:  &transitionWatcher{opts: opts.Stats, view: cycleView{interval: opts.Stats.ProbeInterval}},
+	}
+	s.debugSources["outage_tracker"] = s.transitions.debugState
+	httpServer.Handler = s.withCORS(s.routes())
+	return s
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server. After Shutdown it returns http.ErrServerClosed.
 func (s *Server) Start(addr string) error {
-	http.HandleFunc("/", s.handleIndex)
-	http.HandleFunc("/api/logs", s.handleLogs)
-	http.HandleFunc("/api/stats", s.handleStats)
+	ln, err := s.listen(addr)
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("Starting web dashboard at http://%s\n", addr)
-	return http.ListenAndServe(addr, nil)
+	return s.httpServer.Serve(ln)
 }
 
-// handleIndex serves the dashboard HTML
-func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, s.webDir+"/index.html")
+// Handler returns the server's routes wrapped in its middleware, for
+// serving them without Start, e.g. from httptest
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
 }
 
-// handleLogs returns log entries with optional time filtering
-func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+// routes registers every endpoint on a mux of the server's own, so several
+// servers can run in one process
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/api/logs", s.compressed(s.handleLogs))
+	mux.HandleFunc("/api/logs.jsonl", s.requireAuth(s.compressed(s.handleLogsStream)))
+	mux.HandleFunc("/api/export", s.compressed(s.handleExport))
+	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/diff", s.handleDiff)
+	mux.HandleFunc("/api/addresses", s.compressed(s.handleAddresses))
+	mux.HandleFunc("/api/calendar", s.compressed(s.handleCalendar))
+	mux.HandleFunc("/api/timeline", s.handleTimeline)
+	mux.HandleFunc("/api/stream", s.handleResultStream)
+	mux.HandleFunc("/api/downtime/stream", s.handleDowntimeStream)
+	mux.HandleFunc("/api/debug/state", s.requireAuth(s.handleDebugState))
+	mux.HandleFunc("/api/ingest", s.requireAuth(s.handleIngest))
+	mux.HandleFunc("/grafana/", s.handleGrafanaRoot)
+	mux.HandleFunc("/grafana/search", s.handleGrafanaSearch)
+	mux.HandleFunc("/grafana/query", s.handleGrafanaQuery)
+	mux.HandleFunc("/grafana/annotations", s.handleGrafanaAnnotations)
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics)
+	}
+	return mux
+}
 
-	// Parse query parameters for time range
-	var startTime, endTime *time.Time
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish until ctx is done. Live streams are closed rather than waited for.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
 
-	if startStr := r.URL.Query().Get("start"); startStr != "" {
-		if t, err := time.Parse(time.RFC3339, startStr); err == nil {
-			startTime = &t
-		}
+// handleIndex serves the dashboard HTML, falling back to the embedded
+// status page when no external web directory is available
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	indexPath := filepath.Join(s.webDir, "index.html")
+	if _, err := os.Stat(indexPath); err == nil {
+		http.ServeFile(w, r, indexPath)
+		return
 	}
 
-	if endStr := r.URL.Query().Get("end"); endStr != "" {
-		if t, err := time.Parse(time.RFC3339, endStr); err == nil {
-			endTime = &t
-		}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(statusPage)
+}
+
+// handleLogs returns log entries with optional time filtering. With limit,
+// offset or order it returns one LogPage, newest first by default.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	startTime, endTime, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
 	}
 
-	logs, err := storage.ReadLogs(s.dataDir, startTime, endTime)
+	paging, paged, err := parseLogPaging(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logs, err := s.readLogs(w, startTime, endTime)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(logs)
+	if paged {
+		page := paging.page(logs)
+		writeResponse(w, r, page, logsCSV(w, page.Entries))
+		return
+	}
+	writeResponse(w, r, logs, logsCSV(w, logs))
 }
 
 // Stats represents aggregated statistics
 type Stats struct {
-	CurrentStatus      string          `json:"current_status"` // "online" or "offline"
-	TotalChecks        int             `json:"total_checks"`
-	OnlineChecks       int             `json:"online_checks"`
-	OfflineChecks      int             `json:"offline_checks"`
-	UptimePercentage   float64         `json:"uptime_percentage"`
-	TotalDowntimeHours float64         `json:"total_downtime_hours"`
-	DowntimeEvents     []DowntimeEvent `json:"downtime_events"`
-	RecentDowntime     *DowntimeEvent  `json:"recent_downtime,omitempty"`
-	TimeSinceLastCheck *time.Time      `json:"time_since_last_check,omitempty"`
+	CurrentStatus              string               `json:"current_status"` // "online", "offline" or "paused"
+	TotalChecks                int                  `json:"total_checks"`
+	OnlineChecks               int                  `json:"online_checks"`
+	OfflineChecks              int                  `json:"offline_checks"`
+	PausedChecks               int                  `json:"paused_checks"` // cycles skipped by the pause schedule
+	UptimePercentage           float64              `json:"uptime_percentage"`
+	ProbeSuccessPercentage     float64              `json:"probe_success_percentage"`      // across all probes in range
+	LastCycleSuccessPercentage float64              `json:"last_cycle_success_percentage"` // probes in the latest cycle
+	SuspectLatencies           int                  `json:"suspect_latencies"`             // successes with implausible latency
+	LatencyP50                 float64              `json:"latency_p50_ms"`                // nearest-rank, over every host's raw trusted latencies
+	LatencyP95                 float64              `json:"latency_p95_ms"`
+	LatencyP99                 float64              `json:"latency_p99_ms"`
+	LatencyJitter              float64              `json:"latency_jitter_ms"` // mean of every host's consecutive differences
+	TotalDowntimeHours         float64              `json:"total_downtime_hours"`
+	DowntimeEvents             []DowntimeEvent      `json:"downtime_events"`
+	OmittedDowntimeEvents      int                  `json:"omitted_downtime_events,omitempty"` // dropped by min_duration or max_events
+	RecentDowntime             *DowntimeEvent       `json:"recent_downtime,omitempty"`
+	TimeSinceLastCheck         *time.Time           `json:"time_since_last_check,omitempty"`
+	MonitoringStalled          bool                 `json:"monitoring_stalled"`      // no check for StaleAfter
+	StaleSeconds               float64              `json:"stale_seconds,omitempty"` // age of the latest check, with StaleAfter
+	PerHost                    map[string]HostStats `json:"per_host"`
+	Quality                    *Quality             `json:"quality,omitempty"`
+	Simulated                  bool                 `json:"simulated,omitempty"` // range contains synthetic results
+	MonitoringGaps             int                  `json:"monitoring_gaps,omitempty"`
+
+	// The run of online or offline cycles ending at the latest check; only
+	// one is set. Paused cycles neither extend nor break a streak.
+	CurrentSuccessStreak *Streak `json:"current_success_streak,omitempty"`
+	CurrentFailureStreak *Streak `json:"current_failure_streak,omitempty"`
 }
 
 // DowntimeEvent represents a period of internet connectivity loss
@@ -87,144 +257,269 @@ type DowntimeEvent struct {
 	Duration    int64      `json:"duration_seconds"`
 	IsOngoing   bool       `json:"is_ongoing"`
 	FailedHosts []string   `json:"failed_hosts"`
+	Severity    Severity   `json:"severity"` // graded by duration; ongoing events may still escalate
+
+	// DegradedHosts refused connections under the degraded refused policy.
+	// They count towards the internet being up, so they aren't failed hosts.
+	DegradedHosts []string `json:"degraded_hosts,omitempty"`
+
+	// Set when brief recoveries shorter than the merge gap were collapsed
+	Intermittent bool `json:"intermittent,omitempty"`
+	MergedEvents int  `json:"merged_events,omitempty"`
+
+	// Set when the outage continued across a monitoring gap
+	SpansGap bool `json:"spans_gap,omitempty"`
+
+	// Diagnosis hints at the probable cause, from the failed probes' error codes
+	Diagnosis Diagnosis `json:"diagnosis,omitempty"`
+
+	causes failureCauses
 }
 
 // handleStats returns aggregated statistics
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	startTime, endTime, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
 
-	// Parse query parameters for time range
-	var startTime, endTime *time.Time
+	logs, err := s.readLogs(w, startTime, endTime)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+		return
+	}
 
-	if startStr := r.URL.Query().Get("start"); startStr != "" {
-		if t, err := time.Parse(time.RFC3339, startStr); err == nil {
-			startTime = &t
+	opts := s.statsOpts
+	if gapStr := r.URL.Query().Get("merge_gap"); gapStr != "" {
+		gap, err := time.ParseDuration(gapStr)
+		if err != nil || gap < 0 {
+			http.Error(w, "Invalid merge_gap parameter, expected a duration like 2m", http.StatusBadRequest)
+			return
 		}
+		opts.MergeGap = gap
 	}
-
-	if endStr := r.URL.Query().Get("end"); endStr != "" {
-		if t, err := time.Parse(time.RFC3339, endStr); err == nil {
-			endTime = &t
+	if minStr := r.URL.Query().Get("min_duration"); minStr != "" {
+		minDuration, err := time.ParseDuration(minStr)
+		if err != nil || minDuration < 0 {
+			http.Error(w, "Invalid min_duration parameter, expected a duration like 30s", http.StatusBadRequest)
+			return
+		}
+		opts.MinDowntime = minDuration
+	}
+	if maxStr := r.URL.Query().Get("max_events"); maxStr != "" {
+		maxEvents, err := strconv.Atoi(maxStr)
+		if err != nil || maxEvents < 0 {
+			http.Error(w, "Invalid max_events parameter, expected a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		opts.MaxDowntimeEvents = maxEvents
+	}
+	if capStr := r.URL.Query().Get("latency_cap"); capStr != "" {
+		latencyCap, err := time.ParseDuration(capStr)
+		if err != nil || latencyCap < 0 {
+			http.Error(w, "Invalid latency_cap parameter, expected a duration like 2s", http.StatusBadRequest)
+			return
+		}
+		opts.LatencyCap = latencyCap
+	}
+	if sample := r.URL.Query().Get("sample"); sample != "" {
+		switch DowntimeSampling(sample) {
+		case SampleLongest, SampleEven:
+			opts.DowntimeSampling = DowntimeSampling(sample)
+		default:
+			http.Error(w, "Invalid sample parameter, expected longest or even", http.StatusBadRequest)
+			return
 		}
 	}
 
-	logs, err := storage.ReadLogs(s.dataDir, startTime, endTime)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
-		return
+	// Staleness only means something for ranges reaching the present
+	if endTime != nil && endTime.Before(opts.now()) {
+		opts.StaleAfter = 0
 	}
 
-	stats := calculateStats(logs)
-	json.NewEncoder(w).Encode(stats)
+	stats := calculateStats(logs, opts)
+	writeResponse(w, r, stats, statsCSV(stats))
+}
+
+// isPaused reports whether a log entry is a pause-schedule marker
+func isPaused(entry storage.LogEntry) bool {
+	if len(entry.Results) == 0 {
+		return false
+	}
+	for _, result := range entry.Results {
+		if !result.Paused {
+			return false
+		}
+	}
+	return true
 }
 
 // calculateStats computes statistics from log entries
-// Internet is considered DOWN only when ALL hosts fail to respond
-func calculateStats(logs []storage.LogEntry) Stats {
-	var downtimeEvents []DowntimeEvent
-	var onlineChecks, offlineChecks int
-	var totalDowntimeSeconds int64
-
-	var lastStatus bool // true = online, false = offline
-	var downtimeStart time.Time
-	var downtimeFailedHosts []string
+// Whether the internet is DOWN for a cycle is decided by monitor.IsInternetUp
+// with the configured criteria; by default that is only when ALL hosts fail
+func calculateStats(logs []storage.LogEntry, opts StatsOptions) Stats {
+	var pausedChecks int
+	var probesSent, probesReceived int
+	var lastCycleSuccess float64
+	var suspectLatencies int
+	var latencies latencyDistribution
+	var simulated bool
+	var monitoringGaps int
+	hosts := make(map[string]*hostAccumulator)
+
+	var downtime downtimeTracker
+	var cycles cycleCounter
 	var lastCheckTime *time.Time
+	view := cycleView{interval: opts.ProbeInterval}
 	currentStatus := "online"
 
-	statusInitialized := false
-
 	for _, entry := range logs {
-		// Check if ALL hosts failed (= internet is down)
-		allFailed := true
-		var failedHosts []string
+		paused := isPaused(entry)
+		judged := view.results(entry)
+		internetOnline, cycleSuccess, failedHosts := opts.evaluateCycle(judged)
+		onlineCycles, offlineCycles, pausedCycles := cycleCounts(entry, internetOnline, paused)
+		if entry.Aggregate != nil {
+			// Roll-ups lose per-cycle order, so a window only counts as
+			// down for downtime events when none of its cycles were online
+			paused = onlineCycles+offlineCycles == 0
+			internetOnline = onlineCycles > 0
+		}
 
-		for _, result := range entry.Results {
-			if result.Success {
-				allFailed = false
+		// A monitoring gap (e.g. a restart) bracketed by downtime on both
+		// sides continues the outage when BridgeGaps is set; otherwise the
+		// outage is closed where monitoring stopped
+		if lastCheckTime != nil && opts.GapThreshold > 0 && entry.Timestamp.Sub(*lastCheckTime) > opts.GapThreshold {
+			monitoringGaps++
+			if opts.BridgeGaps && !paused && !internetOnline {
+				downtime.bridge()
 			} else {
-				failedHosts = append(failedHosts, result.Host)
+				downtime.up(*lastCheckTime)
+			}
+		}
+
+		// Paused cycles are intentional gaps: they end any open downtime
+		// (nothing is observed while paused) and count as neither up nor down
+		pausedChecks += pausedCycles
+		if paused {
+			cycles.flush()
+			downtime.up(entry.Timestamp)
+			lastCheckTime = &entry.Timestamp
+			currentStatus = "paused"
+			continue
+		}
+
+		lastCycleSuccess = cycleSuccess
+
+		for _, result := range entry.Results {
+			sent, received := probeCounts(result)
+			probesSent += sent
+			probesReceived += received
+
+			if result.Success && !result.LatencyTrusted() {
+				suspectLatencies++
+			}
+			if result.Aggregate == nil && result.LatencyTrusted() {
+				latencies.add(result.Latency)
 			}
+			if result.Simulated {
+				simulated = true
+			}
+
+			acc, ok := hosts[result.Host]
+			if !ok {
+				acc = &hostAccumulator{}
+				hosts[result.Host] = acc
+			}
+			acc.add(result, opts)
 		}
 
-		internetOnline := !allFailed
 		lastCheckTime = &entry.Timestamp
 
+		if entry.Aggregate != nil {
+			cycles.addRollup(entry, onlineCycles, offlineCycles)
+		} else {
+			cycles.add(entry, internetOnline)
+		}
 		if internetOnline {
-			onlineChecks++
-
-			// Check if this ends a downtime period
-			if statusInitialized && !lastStatus {
-				endTime := entry.Timestamp
-				duration := int64(endTime.Sub(downtimeStart).Seconds())
-				totalDowntimeSeconds += duration
-
-				downEvent := DowntimeEvent{
-					StartTime:   downtimeStart,
-					EndTime:     &endTime,
-					Duration:    duration,
-					IsOngoing:   false,
-					FailedHosts: downtimeFailedHosts,
-				}
-				downtimeEvents = append(downtimeEvents, downEvent)
+			downtime.up(entry.Timestamp)
+			// A daily summary's outages can't be placed within its day,
+			// so only their total carries over
+			if entry.Aggregate != nil {
+				downtime.totalSeconds += entry.Aggregate.DowntimeSeconds
 			}
-			lastStatus = true
 			currentStatus = "online"
 		} else {
-			offlineChecks++
-
-			// Check if this starts a new downtime period
-			if !statusInitialized || lastStatus {
-				downtimeStart = entry.Timestamp
-				downtimeFailedHosts = failedHosts
-			}
-			lastStatus = false
+			downtime.down(entryStart(entry), failedHosts, monitor.DegradedHosts(judged, opts.Up), judged)
 			currentStatus = "offline"
 		}
-
-		statusInitialized = true
 	}
 
 	// Handle ongoing downtime
-	if statusInitialized && !lastStatus && lastCheckTime != nil {
-		duration := int64(time.Since(downtimeStart).Seconds())
-		downEvent := DowntimeEvent{
-			StartTime:   downtimeStart,
-			EndTime:     nil,
-			Duration:    duration,
-			IsOngoing:   true,
-			FailedHosts: downtimeFailedHosts,
-		}
-		downtimeEvents = append(downtimeEvents, downEvent)
-		totalDowntimeSeconds += duration
+	downtime.finish(opts.now())
+	downtimeEvents := mergeDowntime(downtime.events, opts.MergeGap)
+	for i := range downtimeEvents {
+		downtimeEvents[i].Severity = opts.Severity.classify(time.Duration(downtimeEvents[i].Duration) * time.Second)
 	}
 
-	totalChecks := len(logs)
+	cycles.flush()
+	onlineChecks, offlineChecks := cycles.online, cycles.offline
+	totalChecks := onlineChecks + offlineChecks
 	uptimePercentage := 0.0
 	if totalChecks > 0 {
 		uptimePercentage = float64(onlineChecks) / float64(totalChecks) * 100
 	}
 
+	probeSuccessPercentage := 0.0
+	if probesSent > 0 {
+		probeSuccessPercentage = float64(probesReceived) / float64(probesSent) * 100
+	}
+
 	// Sort downtime events by start time (most recent first)
 	for i := 0; i < len(downtimeEvents)/2; i++ {
 		j := len(downtimeEvents) - 1 - i
 		downtimeEvents[i], downtimeEvents[j] = downtimeEvents[j], downtimeEvents[i]
 	}
 
+	perHost := make(map[string]HostStats, len(hosts))
+	for host, acc := range hosts {
+		perHost[host] = acc.finish()
+	}
+
 	var recentDowntime *DowntimeEvent
 	if len(downtimeEvents) > 0 {
-		recentDowntime = &downtimeEvents[0]
+		recent := downtimeEvents[0]
+		recentDowntime = &recent
 	}
-
-	return Stats{
-		CurrentStatus:      currentStatus,
-		TotalChecks:        totalChecks,
-		OnlineChecks:       onlineChecks,
-		OfflineChecks:      offlineChecks,
-		UptimePercentage:   uptimePercentage,
-		TotalDowntimeHours: float64(totalDowntimeSeconds) / 3600,
-		DowntimeEvents:     downtimeEvents,
-		RecentDowntime:     recentDowntime,
-		TimeSinceLastCheck: lastCheckTime,
+	downtimeEvents, omittedDowntime := boundDowntime(downtimeEvents, opts)
+
+	stats := Stats{
+		CurrentStatus:              currentStatus,
+		TotalChecks:                totalChecks,
+		OnlineChecks:               onlineChecks,
+		OfflineChecks:              offlineChecks,
+		PausedChecks:               pausedChecks,
+		UptimePercentage:           uptimePercentage,
+		ProbeSuccessPercentage:     probeSuccessPercentage,
+		LastCycleSuccessPercentage: lastCycleSuccess,
+		SuspectLatencies:           suspectLatencies,
+		TotalDowntimeHours:         float64(downtime.totalSeconds) / 3600,
+		DowntimeEvents:             downtimeEvents,
+		OmittedDowntimeEvents:      omittedDowntime,
+		RecentDowntime:             recentDowntime,
+		TimeSinceLastCheck:         lastCheckTime,
+		PerHost:                    perHost,
+		Simulated:                  simulated,
+		MonitoringGaps:             monitoringGaps,
+	}
+	stats.CurrentSuccessStreak, stats.CurrentFailureStreak = cycles.streak.current()
+	stats.LatencyP50, stats.LatencyP95, stats.LatencyP99 = latencies.percentiles()
+	stats.LatencyJitter = pooledJitter(hosts)
+	stats.Quality = calculateQuality(stats, opts.ScoreWeights)
+	if opts.StaleAfter > 0 && lastCheckTime != nil {
+		age := opts.now().Sub(*lastCheckTime)
+		stats.StaleSeconds = age.Seconds()
+		stats.MonitoringStalled = age > opts.StaleAfter
 	}
+	return stats
 }
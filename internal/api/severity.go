@@ -0,0 +1,38 @@
+package api
+
+import "time"
+
+// Severity grades a downtime event by how long it lasted
+type Severity string
+
+const (
+	SeverityMinor    Severity = "minor"
+	SeverityMajor    Severity = "major"
+	SeverityCritical Severity = "critical"
+)
+
+// SeverityThresholds sets the durations at which an outage becomes major
+// and critical; shorter outages are minor
+type SeverityThresholds struct {
+	Major    time.Duration
+	Critical time.Duration
+}
+
+// DefaultSeverityThresholds grades outages under a minute as minor and
+// those of 15 minutes or more as critical
+var DefaultSeverityThresholds = SeverityThresholds{Major: time.Minute, Critical: 15 * time.Minute}
+
+// classify returns the severity of an outage lasting duration
+func (t SeverityThresholds) classify(duration time.Duration) Severity {
+	if t.Major <= 0 && t.Critical <= 0 {
+		t = DefaultSeverityThresholds
+	}
+	switch {
+	case t.Critical > 0 && duration >= t.Critical:
+		return SeverityCritical
+	case t.Major > 0 && duration >= t.Major:
+		return SeverityMajor
+	default:
+		return SeverityMinor
+	}
+}
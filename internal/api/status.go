@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// LiveStatus is a lightweight view of the monitor's current state, served
+// without reading the log files
+type LiveStatus struct {
+	CurrentStatus string                       `json:"current_status"` // "online", "offline" or "unknown"
+	Restored      bool                         `json:"restored"`       // from the startup snapshot; no cycle has run yet
+	LastCycle     *time.Time                   `json:"last_cycle,omitempty"`
+	OutageStart   *time.Time                   `json:"outage_start,omitempty"`
+	Hosts         map[string]monitor.HostState `json:"hosts"`
+}
+
+// handleStatus returns the live status from the monitor's in-memory state
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.live == nil {
+		http.Error(w, "Live status is not available", http.StatusNotFound)
+		return
+	}
+
+	state := s.live()
+	status := LiveStatus{
+		CurrentStatus: "unknown",
+		Restored:      state.Restored,
+		LastCycle:     state.LastCycle,
+		OutageStart:   state.OutageStart,
+		Hosts:         state.Hosts,
+	}
+	if len(state.Hosts) > 0 {
+		status.CurrentStatus = "offline"
+		if state.OutageStart == nil {
+			status.CurrentStatus = "online"
+		}
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
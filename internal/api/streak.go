@@ -0,0 +1,54 @@
+package api
+
+import "time"
+
+// Streak is a run of consecutive successful or failed checks ending at the
+// latest check in range
+type Streak struct {
+	Checks   int       `json:"checks"`
+	Since    time.Time `json:"since"`
+	Duration int64     `json:"duration_seconds"` // from the first check of the run to the latest
+}
+
+// streakTracker follows the current run of identical outcomes in a single
+// chronological pass
+type streakTracker struct {
+	started bool
+	success bool
+	checks  int
+	since   time.Time
+	last    time.Time
+}
+
+// add folds in checks checks with the same outcome spanning start to end.
+// mixed marks a roll-up containing both outcomes, whose order is unknown;
+// it starts a new run counting only the checks matching success.
+func (t *streakTracker) add(success bool, checks int, start, end time.Time, mixed bool) {
+	if checks <= 0 {
+		return
+	}
+	if !t.started || success != t.success || mixed {
+		t.started = true
+		t.success = success
+		t.checks = 0
+		t.since = start
+	}
+	t.checks += checks
+	t.last = end
+}
+
+// current returns the run as a success or a failure streak; the other is nil
+func (t *streakTracker) current() (success, failure *Streak) {
+	if !t.started {
+		return nil, nil
+	}
+	streak := &Streak{
+		Checks:   t.checks,
+		Since:    t.since,
+		Duration: int64(t.last.Sub(t.since).Seconds()),
+	}
+	if t.success {
+		return streak, nil
+	}
+	return nil, streak
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server-sent event stream settings
+const (
+	streamBuffer    = 16               // messages queued per client before it is dropped
+	streamKeepAlive = 30 * time.Second // comment sent to idle clients so proxies keep the connection
+)
+
+// streamMessage is one server-sent event
+type streamMessage struct {
+	event string
+	data  []byte
+}
+
+// broadcaster fans messages out to subscribed stream clients. A client
+// that falls behind is disconnected rather than slowing the publisher.
+type broadcaster struct {
+	mu      sync.Mutex
+	clients map[chan streamMessage]bool
+}
+
+// subscribe registers a client; the channel is closed when it is dropped
+func (b *broadcaster) subscribe() chan streamMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.clients == nil {
+		b.clients = make(map[chan streamMessage]bool)
+	}
+	ch := make(chan streamMessage, streamBuffer)
+	b.clients[ch] = true
+	return ch
+}
+
+// unsubscribe removes a client, if it hasn't been dropped already
+func (b *broadcaster) unsubscribe(ch chan streamMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.clients[ch] {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// publish sends v as JSON to every client under the given event name
+func (b *broadcaster) publish(event string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- streamMessage{event: event, data: data}:
+		default:
+			delete(b.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// serveStream writes messages from b to the client as server-sent events
+// until the client disconnects or is dropped
+func serveStream(w http.ResponseWriter, r *http.Request, b *broadcaster) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.event, msg.data)
+		}
+		flusher.Flush()
+	}
+}
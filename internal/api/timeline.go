@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// TimelineState is the aggregate internet state of a timeline span
+type TimelineState string
+
+const (
+	TimelineOnline   TimelineState = "online"   // up, with every host answering
+	TimelineDegraded TimelineState = "degraded" // up by the criterion, but some hosts failing
+	TimelineOffline  TimelineState = "offline"  // down by the criterion
+	TimelinePaused   TimelineState = "paused"   // probing paused by schedule
+	TimelineNoData   TimelineState = "no_data"  // a monitoring gap (see MONITORING_GAP)
+)
+
+// TimelineSpan is a run of consecutive cycles in the same state. Spans are
+// contiguous: each ends where the next begins.
+type TimelineSpan struct {
+	State  TimelineState `json:"state"`
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Cycles int           `json:"cycles"`
+}
+
+// handleTimeline returns the aggregate state over the range as
+// run-length-encoded spans, for drawing a single status bar
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	startTime, endTime, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	timeline := timelineBuilder{opts: s.statsOpts, view: cycleView{interval: s.statsOpts.ProbeInterval}, spans: []TimelineSpan{}}
+	if scanner, ok := s.store.(storage.Scanner); ok && len(s.peers) == 0 {
+		// Local logs are scanned in order without loading the range
+		if err := scanner.ScanLogs(startTime, endTime, timeline.add); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		logs, err := s.readLogs(w, startTime, endTime)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read logs: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, entry := range logs {
+			timeline.add(entry)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline.spans)
+}
+
+// timelineBuilder collapses chronological entries into state spans
+type timelineBuilder struct {
+	opts      StatsOptions
+	spans     []TimelineSpan
+	lastCheck time.Time
+	view      cycleView
+}
+
+// add extends the timeline with one entry
+func (b *timelineBuilder) add(entry storage.LogEntry) {
+	start := entryStart(entry)
+	if n := len(b.spans); n > 0 {
+		if b.opts.GapThreshold > 0 && entry.Timestamp.Sub(b.lastCheck) > b.opts.GapThreshold {
+			b.spans = append(b.spans, TimelineSpan{State: TimelineNoData, Start: b.lastCheck, End: start})
+		} else if start.After(b.spans[n-1].End) {
+			// Close the previous span where this entry's cycles begin
+			b.spans[n-1].End = start
+		}
+	}
+	b.lastCheck = entry.Timestamp
+
+	state, cycles := b.entryState(entry)
+	if n := len(b.spans); n > 0 && b.spans[n-1].State == state {
+		b.spans[n-1].End = entry.Timestamp
+		b.spans[n-1].Cycles += cycles
+		return
+	}
+	b.spans = append(b.spans, TimelineSpan{State: state, Start: start, End: entry.Timestamp, Cycles: cycles})
+}
+
+// entryState classifies an entry and returns how many cycles it covers. A
+// roll-up is offline only if none of its cycles were online, and degraded
+// if any cycle or probe failed.
+func (b *timelineBuilder) entryState(entry storage.LogEntry) (TimelineState, int) {
+	paused := isPaused(entry)
+	results := b.view.results(entry)
+	online, _, failedHosts := b.opts.evaluateCycle(results)
+	onlineCycles, offlineCycles, pausedCycles := cycleCounts(entry, online, paused)
+	cycles := onlineCycles + offlineCycles + pausedCycles
+
+	if entry.Aggregate != nil {
+		switch {
+		case onlineCycles+offlineCycles == 0:
+			return TimelinePaused, cycles
+		case onlineCycles == 0:
+			return TimelineOffline, cycles
+		case offlineCycles > 0:
+			return TimelineDegraded, cycles
+		}
+		for _, result := range entry.Results {
+			if agg := result.Aggregate; agg != nil && agg.Successes < agg.Cycles {
+				return TimelineDegraded, cycles
+			}
+		}
+		return TimelineOnline, cycles
+	}
+
+	switch {
+	case paused:
+		return TimelinePaused, cycles
+	case !online:
+		return TimelineOffline, cycles
+	case len(failedHosts) > 0, len(monitor.DegradedHosts(results, b.opts.Up)) > 0:
+		return TimelineDegraded, cycles
+	}
+	return TimelineOnline, cycles
+}
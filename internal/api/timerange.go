@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// parseTimeRange reads the optional RFC3339 start and end query parameters.
+// A value that doesn't parse, or a start after the end, is an error rather
+// than being dropped, so a typo never quietly returns the full range.
+func parseTimeRange(query url.Values) (startTime, endTime *time.Time, err error) {
+	if startStr := query.Get("start"); startStr != "" {
+		t, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid start parameter, expected RFC3339")
+		}
+		startTime = &t
+	}
+
+	if endStr := query.Get("end"); endStr != "" {
+		t, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid end parameter, expected RFC3339")
+		}
+		endTime = &t
+	}
+
+	if startTime != nil && endTime != nil && startTime.After(*endTime) {
+		return nil, nil, fmt.Errorf("invalid time range, start is after end")
+	}
+	return startTime, endTime, nil
+}
+
+// writeQueryError responds 400 with the error as {"error": "..."}
+func writeQueryError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"monitrix/internal/alert"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// Transition kinds, also used as stream event names
+const (
+	transitionDown = "downtime_start"
+	transitionUp   = "downtime_end"
+)
+
+// transition is an outage beginning or ending. The event is ongoing for
+// transitionDown and closed for transitionUp.
+type transition struct {
+	kind  string
+	event DowntimeEvent
+}
+
+// alert converts the transition for notifiers
+func (t *transition) alert() alert.Alert {
+	kind := alert.KindDown
+	if t.kind == transitionUp {
+		kind = alert.KindUp
+	}
+	return alert.Alert{
+		Kind:        kind,
+		StartTime:   t.event.StartTime,
+		EndTime:     t.event.EndTime,
+		Duration:    t.event.Duration,
+		FailedHosts: t.event.FailedHosts,
+		Severity:    string(t.event.Severity),
+		Diagnosis:   string(t.event.Diagnosis),
+	}
+}
+
+// transitionWatcher applies the stats up criteria to live cycles and
+// reports when the internet goes down or comes back. It uses the same
+// downtime tracking as /api/stats, so events match what stats later report.
+type transitionWatcher struct {
+	opts     StatsOptions
+	mu       sync.Mutex
+	downtime downtimeTracker
+	view     cycleView
+}
+
+// outageState is the outage being tracked, as shown by /api/debug/state
+type outageState struct {
+	Active      bool       `json:"active"`
+	StartTime   *time.Time `json:"start_time,omitempty"`
+	FailedHosts []string   `json:"failed_hosts,omitempty"`
+	SpansGap    bool       `json:"spans_gap,omitempty"`
+}
+
+// debugState returns the outage being tracked, if any
+func (w *transitionWatcher) debugState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.downtime.active {
+		return outageState{}
+	}
+	start := w.downtime.start
+	return outageState{
+		Active:      true,
+		StartTime:   &start,
+		FailedHosts: w.downtime.failedHosts,
+		SpansGap:    w.downtime.spansGap,
+	}
+}
+
+// observe folds one cycle's results into the watcher, returning the
+// transition it caused, if any
+func (w *transitionWatcher) observe(results []monitor.PingResult, at time.Time) *transition {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	paused := true
+	for _, result := range results {
+		if !result.Paused {
+			paused = false
+			break
+		}
+	}
+	judged := w.view.results(storage.LogEntry{Timestamp: at, Results: results})
+	online, _, failedHosts := w.opts.evaluateCycle(judged)
+
+	wasDown := w.downtime.active
+	if paused || online {
+		w.downtime.up(at)
+	} else {
+		w.downtime.down(at, failedHosts, monitor.DegradedHosts(judged, w.opts.Up), judged)
+	}
+
+	switch {
+	case !wasDown && w.downtime.active:
+		event := DowntimeEvent{
+			StartTime:     w.downtime.start,
+			IsOngoing:     true,
+			FailedHosts:   w.downtime.failedHosts,
+			DegradedHosts: w.downtime.degraded,
+			Severity:      w.opts.Severity.classify(0),
+			Diagnosis:     w.downtime.causes.diagnosis(),
+		}
+		return &transition{kind: transitionDown, event: event}
+	case wasDown && !w.downtime.active:
+		event := w.downtime.events[len(w.downtime.events)-1]
+		w.downtime.events = nil // only the latest event is needed
+		event.Severity = w.opts.Severity.classify(time.Duration(event.Duration) * time.Second)
+		return &transition{kind: transitionUp, event: event}
+	}
+	return nil
+}
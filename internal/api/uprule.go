@@ -0,0 +1,138 @@
+package api
+
+import (
+	"time"
+
+	"monitrix/internal/clock"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// StatsOptions controls how statistics are computed
+type StatsOptions struct {
+	Up        monitor.UpCriteria // decides whether each cycle counts as online
+	EWMAAlpha float64            // smoothing factor for per-host EWMA latency, in (0, 1]
+
+	// MergeGap collapses downtime events separated by recoveries shorter
+	// than this into one intermittent event; zero keeps raw events
+	MergeGap time.Duration
+
+	// GapThreshold marks consecutive cycles further apart than this as a
+	// monitoring gap; zero disables gap detection. With BridgeGaps, a gap
+	// with downtime on both sides is treated as continued downtime;
+	// otherwise any open outage ends where monitoring stopped.
+	GapThreshold time.Duration
+	BridgeGaps   bool
+
+	// Downtime list bounds: events shorter than MinDowntime are dropped, then
+	// at most MaxDowntimeEvents are returned, chosen by DowntimeSampling.
+	// Totals are always computed from every event.
+	MinDowntime       time.Duration
+	MaxDowntimeEvents int
+	DowntimeSampling  DowntimeSampling
+
+	// LatencyCap winsorizes latencies for the per-host averages: samples above
+	// it count at the cap (and are tallied as capped). Stored results keep
+	// their raw values. Zero disables the cap.
+	LatencyCap time.Duration
+
+	Severity     SeverityThresholds // duration thresholds grading each downtime event
+	ScoreWeights ScoreWeights       // weights of the connection quality score
+
+	// StaleAfter marks monitoring as stalled when the latest check is older
+	// than this, so a dead monitor isn't shown as a healthy last status.
+	// Zero disables the check, as for ranges ending in the past.
+	StaleAfter time.Duration
+
+	// ProbeInterval is the longest probe interval of any host. A host's
+	// latest result stands in for it for twice this long until its next
+	// result shows the actual spacing; zero keeps it until then.
+	ProbeInterval time.Duration
+
+	// Clock is the present that ongoing downtime runs to and staleness is
+	// measured from; defaults to the real clock
+	Clock clock.Clock
+}
+
+// now returns the current time by the configured clock
+func (o StatsOptions) now() time.Time {
+	if o.Clock == nil {
+		return time.Now()
+	}
+	return o.Clock.Now()
+}
+
+// checkCounts returns how many checks a result represents and how many succeeded
+func checkCounts(result monitor.PingResult) (checks, succeeded int) {
+	if result.Paused {
+		return 0, 0
+	}
+	if result.Aggregate != nil {
+		return result.Aggregate.Cycles, result.Aggregate.Successes
+	}
+	if result.Success {
+		return 1, 1
+	}
+	return 1, 0
+}
+
+// probeCounts returns how many probes a result represents and how many were
+// answered. A check with PROBES_PER_CHECK counts each of its probes, so
+// partial loss shows; otherwise it is the same as checkCounts.
+func probeCounts(result monitor.PingResult) (sent, received int) {
+	if result.ProbesSent > 0 && !result.Paused && result.Aggregate == nil {
+		return result.ProbesSent, result.ProbesReceived
+	}
+	return checkCounts(result)
+}
+
+// cycleView carries host results from entry to entry. Each host is probed
+// on its own ticker, so an entry holds only some of the hosts and is judged
+// together with the latest results of the rest. Peers are tracked apart
+// from this instance and each other, so an entry is judged on every
+// source's hosts instead of one source's results replacing another's.
+type cycleView struct {
+	interval time.Duration // see StatsOptions.ProbeInterval
+	sources  map[string]*monitor.LatestResults
+	order    []string
+}
+
+// results returns the results entry is judged on
+func (v *cycleView) results(entry storage.LogEntry) []monitor.PingResult {
+	if v.sources == nil {
+		v.sources = make(map[string]*monitor.LatestResults)
+	}
+	latest, ok := v.sources[entry.Source]
+	if !ok {
+		latest = &monitor.LatestResults{Interval: v.interval}
+		v.sources[entry.Source] = latest
+		v.order = append(v.order, entry.Source)
+	}
+	if len(v.order) == 1 {
+		return latest.Update(entry.Results)
+	}
+
+	latest.Update(entry.Results)
+	var results []monitor.PingResult
+	for _, source := range v.order {
+		results = append(results, v.sources[source].Current(entry.Timestamp)...)
+	}
+	return results
+}
+
+// evaluateCycle decides whether the internet was up during a single cycle.
+// It also returns the percentage of successful probes and the failed hosts.
+func (o StatsOptions) evaluateCycle(results []monitor.PingResult) (online bool, successPercentage float64, failedHosts []string) {
+	var sent, received int
+	for _, result := range results {
+		s, r := probeCounts(result)
+		sent += s
+		received += r
+	}
+	if sent > 0 {
+		successPercentage = float64(received) / float64(sent) * 100
+	}
+
+	online, failedHosts = monitor.IsInternetUp(results, o.Up)
+	return online, successPercentage, failedHosts
+}
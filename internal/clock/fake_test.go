@@ -0,0 +1,81 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// received reports whether c has a value ready
+func received(c <-chan time.Time) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFakeClockAfterFiresOnAdvance(t *testing.T) {
+	f := NewFakeClock(start)
+	c := f.After(time.Minute)
+
+	f.Advance(59 * time.Second)
+	if received(c) {
+		t.Fatal("After fired before its duration had passed")
+	}
+	f.Advance(time.Second)
+	if !received(c) {
+		t.Fatal("After didn't fire once its duration had passed")
+	}
+	if got := f.Now(); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("Now = %v, want %v", got, start.Add(time.Minute))
+	}
+}
+
+func TestFakeClockAfterZeroFiresImmediately(t *testing.T) {
+	f := NewFakeClock(start)
+	if !received(f.After(0)) {
+		t.Fatal("After(0) didn't fire")
+	}
+}
+
+func TestFakeClockTickerDropsMissedTicks(t *testing.T) {
+	f := NewFakeClock(start)
+	ticker := f.NewTicker(time.Second)
+
+	// Five periods at once deliver a single tick, like time.Ticker
+	f.Advance(5 * time.Second)
+	if !received(ticker.C()) {
+		t.Fatal("ticker didn't tick")
+	}
+	if received(ticker.C()) {
+		t.Fatal("ticker queued more than one tick")
+	}
+
+	f.Advance(time.Second)
+	if !received(ticker.C()) {
+		t.Fatal("ticker didn't tick on the next period")
+	}
+
+	ticker.Stop()
+	f.Advance(time.Second)
+	if received(ticker.C()) {
+		t.Fatal("stopped ticker ticked")
+	}
+}
+
+func TestFakeClockSetBackwardDoesNotFire(t *testing.T) {
+	f := NewFakeClock(start)
+	c := f.After(time.Minute)
+
+	f.Set(start.Add(-time.Hour))
+	if received(c) {
+		t.Fatal("After fired when the clock stepped back")
+	}
+	f.Set(start.Add(time.Minute))
+	if !received(c) {
+		t.Fatal("After didn't fire once the clock reached it")
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"monitrix/internal/monitor"
+)
+
+// Storage backends
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+// Config holds the core settings: what to monitor, where to serve and
+// store results, and who to alert. Built-in defaults are overridden by a
+// YAML file, which is in turn overridden by environment variables.
+//
+// The YAML file supports only these keys: hosts, interval, timeout,
+// web_addr, storage.backend, storage.retention_days,
+// notifiers.webhook_url and notifiers.slack_webhook_url. Every other
+// setting (probe tuning, up rules, per-host overrides, stats, peers and so
+// on) is read from the environment alone.
+type Config struct {
+	Hosts     []string      `yaml:"hosts"`    // MONITOR_HOSTS
+	Interval  time.Duration `yaml:"-"`        // MONITOR_INTERVAL; read from the file by fileConfig
+	Timeout   time.Duration `yaml:"-"`        // MONITOR_TIMEOUT; read from the file by fileConfig
+	WebAddr   string        `yaml:"web_addr"` // WEB_ADDR
+	Storage   Storage       `yaml:"storage"`
+	Notifiers Notifiers     `yaml:"notifiers"`
+}
+
+// fileConfig is Config as written in YAML, where interval and timeout are
+// a number of seconds, like their environment variables, or a duration
+// string such as 1m30s
+type fileConfig struct {
+	Config   `yaml:",inline"`
+	Interval *seconds `yaml:"interval"`
+	Timeout  *seconds `yaml:"timeout"`
+}
+
+// seconds is a YAML duration given as whole seconds or a duration string.
+// yaml.v3 would otherwise decode a bare 30 into time.Duration as 30ns.
+type seconds time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (s *seconds) UnmarshalYAML(value *yaml.Node) error {
+	var count int64
+	if err := value.Decode(&count); err == nil {
+		*s = seconds(time.Duration(count) * time.Second)
+		return nil
+	}
+	var text string
+	if err := value.Decode(&text); err != nil {
+		return fmt.Errorf("line %d: expected seconds or a duration like 30s", value.Line)
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return fmt.Errorf("line %d: expected seconds or a duration like 30s, got %q", value.Line, text)
+	}
+	*s = seconds(duration)
+	return nil
+}
+
+// Storage selects where results are kept and for how long
+type Storage struct {
+	Backend       string `yaml:"backend"`        // STORAGE_BACKEND
+	RetentionDays int    `yaml:"retention_days"` // RETENTION_DAYS, 0 keeps everything
+}
+
+// Notifiers are the outage alert destinations; empty ones are disabled
+type Notifiers struct {
+	WebhookURL      string `yaml:"webhook_url"`       // ALERT_WEBHOOK_URL
+	SlackWebhookURL string `yaml:"slack_webhook_url"` // SLACK_WEBHOOK_URL
+}
+
+// Default returns the built-in configuration
+func Default() Config {
+	return Config{
+		// Reliable, geographically distributed services
+		Hosts: []string{
+			"1.1.1.1",        // Cloudflare DNS (very reliable)
+			"8.8.8.8",        // Google DNS (very reliable)
+			"google.com",     // Google (Americas)
+			"cloudflare.com", // Cloudflare (Global CDN)
+			"github.com",     // GitHub (Tech infrastructure)
+		},
+		Interval: monitor.DefaultInterval,
+		Timeout:  monitor.DefaultTimeout,
+		WebAddr:  "0.0.0.0:8080",
+		Storage:  Storage{Backend: BackendFile},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path
+// (skipped when path is empty) and the environment, then validates it
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// readFile overlays the settings present in a YAML file. Unknown keys are
+// rejected so a misspelt setting doesn't silently keep its default.
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	overlay := fileConfig{Config: *c}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&overlay); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	*c = overlay.Config
+	if overlay.Interval != nil {
+		c.Interval = time.Duration(*overlay.Interval)
+	}
+	if overlay.Timeout != nil {
+		c.Timeout = time.Duration(*overlay.Timeout)
+	}
+	return nil
+}
+
+// applyEnv overlays the environment variables that are set
+func (c *Config) applyEnv() error {
+	if value := os.Getenv("MONITOR_HOSTS"); value != "" {
+		c.Hosts = SplitHosts(value)
+	}
+	if value := os.Getenv("MONITOR_INTERVAL"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("MONITOR_INTERVAL: expected a positive number of seconds, got %q", value)
+		}
+		c.Interval = time.Duration(seconds) * time.Second
+	}
+	if value := os.Getenv("MONITOR_TIMEOUT"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("MONITOR_TIMEOUT: expected a positive number of seconds, got %q", value)
+		}
+		c.Timeout = time.Duration(seconds) * time.Second
+	}
+	if value := os.Getenv("WEB_ADDR"); value != "" {
+		c.WebAddr = value
+	}
+	if value := os.Getenv("STORAGE_BACKEND"); value != "" {
+		c.Storage.Backend = value
+	}
+	if value := os.Getenv("RETENTION_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			return fmt.Errorf("RETENTION_DAYS: expected a non-negative number of days, got %q", value)
+		}
+		c.Storage.RetentionDays = days
+	}
+	if value := os.Getenv("ALERT_WEBHOOK_URL"); value != "" {
+		c.Notifiers.WebhookURL = value
+	}
+	if value := os.Getenv("SLACK_WEBHOOK_URL"); value != "" {
+		c.Notifiers.SlackWebhookURL = value
+	}
+	return nil
+}
+
+// SplitHosts parses a comma-separated host list, trimming whitespace
+func SplitHosts(value string) []string {
+	hosts := strings.Split(value, ",")
+	for i, host := range hosts {
+		hosts[i] = strings.TrimSpace(host)
+	}
+	return hosts
+}
+
+// Validate checks the settings are usable. Hosts are only checked for
+// presence; their syntax depends on the probe settings.
+func (c Config) Validate() error {
+	if len(c.Hosts) == 0 {
+		return fmt.Errorf("hosts: at least one host is required")
+	}
+	for _, host := range c.Hosts {
+		if host == "" {
+			return fmt.Errorf("hosts: empty host name")
+		}
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval: must be positive, got %v", c.Interval)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout: must be positive, got %v", c.Timeout)
+	}
+	if c.WebAddr == "" {
+		return fmt.Errorf("web_addr: required")
+	}
+	if c.Storage.Backend != BackendFile && c.Storage.Backend != BackendSQLite {
+		return fmt.Errorf("storage.backend: unknown backend %q, expected file or sqlite", c.Storage.Backend)
+	}
+	if c.Storage.RetentionDays < 0 {
+		return fmt.Errorf("storage.retention_days: must not be negative, got %d", c.Storage.RetentionDays)
+	}
+	return nil
+}
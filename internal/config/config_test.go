@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFileDurations(t *testing.T) {
+	// Keep the environment from overriding the file
+	t.Setenv("MONITOR_INTERVAL", "")
+	t.Setenv("MONITOR_TIMEOUT", "")
+
+	tests := []struct {
+		yaml     string
+		interval time.Duration
+		err      string
+	}{
+		{"interval: 30", 30 * time.Second, ""},
+		{"interval: 1m30s", 90 * time.Second, ""},
+		{"hosts: [a]", Default().Interval, ""},
+		{"interval: 0", 0, "interval: must be positive"},
+		{"interval: -5s", 0, "interval: must be positive"},
+		{"interval: soon", 0, "expected seconds or a duration"},
+		{"interval: 30\nbogus: 1", 0, "field bogus not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.yaml, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "monitrix.yaml")
+			if err := os.WriteFile(path, []byte(test.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error = %v, want one containing %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Interval != test.interval {
+				t.Errorf("interval = %v, want %v", cfg.Interval, test.interval)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"monitrix/internal/alert"
+	"monitrix/internal/monitor"
+	"monitrix/internal/storage"
+)
+
+// DefaultLatencyBuckets are the histogram bucket boundaries in milliseconds
+// used for hosts without an explicit override
+var DefaultLatencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// Metrics collects live probe metrics for Prometheus scraping
+type Metrics struct {
+	registry       *prometheus.Registry
+	defaultBuckets []float64
+	hostBuckets    map[string][]float64
+
+	mu      sync.Mutex
+	latency map[string]prometheus.Histogram
+
+	up          *prometheus.GaugeVec
+	lastLatency *prometheus.GaugeVec
+	checks      prometheus.Counter
+
+	notifications  *prometheus.CounterVec
+	notifyDuration *prometheus.HistogramVec
+}
+
+// New creates a metrics collector. hostBuckets overrides the latency
+// buckets for individual hosts, e.g. tighter ranges for LAN targets.
+func New(defaultBuckets []float64, hostBuckets map[string][]float64) *Metrics {
+	if len(defaultBuckets) == 0 {
+		defaultBuckets = DefaultLatencyBuckets
+	}
+	m := &Metrics{
+		registry:       prometheus.NewRegistry(),
+		defaultBuckets: defaultBuckets,
+		hostBuckets:    hostBuckets,
+		latency:        make(map[string]prometheus.Histogram),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "monitrix_host_up",
+			Help: "Whether the host's latest probe succeeded (1) or failed (0).",
+		}, []string{"host"}),
+		lastLatency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "monitrix_host_latency_ms",
+			Help: "Latency of the host's latest successful probe in milliseconds.",
+		}, []string{"host"}),
+		checks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "monitrix_total_checks",
+			Help: "Monitoring cycles completed, including those in storage at launch.",
+		}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "monitrix_notify_total",
+			Help: "Alert deliveries by notifier and result (success or failure).",
+		}, []string{"notifier", "result"}),
+		notifyDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "monitrix_notify_duration_seconds",
+			Help:    "Time taken to deliver an alert, by notifier.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"notifier"}),
+	}
+	m.registry.MustRegister(m.up, m.lastLatency, m.checks, m.notifications, m.notifyDuration)
+	return m
+}
+
+// Registry returns the underlying registry so other collectors can be
+// exposed alongside the histograms
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns the HTTP handler serving the text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Observe records a cycle's results. Paused results leave the host gauges
+// as they were, and only successful probes with a trusted latency
+// contribute to the latency metrics.
+func (m *Metrics) Observe(results []monitor.PingResult) {
+	m.checks.Inc()
+	for _, result := range results {
+		if result.Paused {
+			continue
+		}
+		up := 0.0
+		if result.Success {
+			up = 1
+		}
+		m.up.WithLabelValues(result.Host).Set(up)
+
+		if !result.LatencyTrusted() {
+			continue
+		}
+		m.lastLatency.WithLabelValues(result.Host).Set(float64(result.Latency))
+		m.histogram(result.Host).Observe(float64(result.Latency))
+	}
+}
+
+// Seed restores the counters and host gauges from stored logs, so they
+// carry on from the logs rather than from zero after a restart. The latency
+// histograms only cover probes since launch.
+func (m *Metrics) Seed(logs []storage.LogEntry) {
+	for _, entry := range logs {
+		if entry.Aggregate != nil {
+			m.checks.Add(float64(entry.Aggregate.Cycles))
+		} else {
+			m.checks.Inc()
+		}
+		for _, result := range entry.Results {
+			if result.Paused {
+				continue
+			}
+			up := 0.0
+			if result.Success || (result.Aggregate != nil && result.Aggregate.Successes > 0) {
+				up = 1
+			}
+			m.up.WithLabelValues(result.Host).Set(up)
+			if result.LatencyTrusted() {
+				m.lastLatency.WithLabelValues(result.Host).Set(float64(result.Latency))
+			}
+		}
+	}
+}
+
+// histogram returns the latency histogram for a host, registering it on first use.
+// Each host gets its own histogram so bucket boundaries can differ per host.
+func (m *Metrics) histogram(host string) prometheus.Histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if h, ok := m.latency[host]; ok {
+		return h
+	}
+
+	buckets := m.defaultBuckets
+	if b, ok := m.hostBuckets[host]; ok && len(b) > 0 {
+		buckets = b
+	}
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:        "monitrix_probe_latency_ms",
+		Help:        "Latency of successful probes in milliseconds.",
+		ConstLabels: prometheus.Labels{"host": host},
+		Buckets:     buckets,
+	})
+	m.registry.MustRegister(h)
+	m.latency[host] = h
+	return h
+}
+
+// CountPanics exposes monitrix_recovered_panics_total, read from count at scrape time
+func (m *Metrics) CountPanics(count func() int64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "monitrix_recovered_panics_total",
+		Help: "Panics recovered in probes and the monitoring loop.",
+	}, func() float64 { return float64(count()) }))
+}
+
+// CountDowntimes exposes monitrix_downtime_events_total, read from count at scrape time
+func (m *Metrics) CountDowntimes(count func() int64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "monitrix_downtime_events_total",
+		Help: "Outages started, including those in storage at launch.",
+	}, func() float64 { return float64(count()) }))
+}
+
+// InstrumentNotifier wraps notifier so each delivery is counted in
+// monitrix_notify_total and timed in monitrix_notify_duration_seconds under
+// the given name, e.g. webhook or slack
+func (m *Metrics) InstrumentNotifier(name string, notifier alert.Notifier) alert.Notifier {
+	return &instrumentedNotifier{Notifier: notifier, name: name, metrics: m}
+}
+
+// instrumentedNotifier records the timing and outcome of the deliveries of
+// the notifier it wraps
+type instrumentedNotifier struct {
+	alert.Notifier
+	name    string
+	metrics *Metrics
+}
+
+// Notify delivers the alert through the wrapped notifier
+func (n *instrumentedNotifier) Notify(a alert.Alert) error {
+	start := time.Now()
+	err := n.Notifier.Notify(a)
+	n.metrics.notifyDuration.WithLabelValues(n.name).Observe(time.Since(start).Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	n.metrics.notifications.WithLabelValues(n.name, result).Inc()
+	return err
+}
+
+// String describes the wrapped notifier, for delivery failure messages
+func (n *instrumentedNotifier) String() string {
+	return fmt.Sprint(n.Notifier)
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"monitrix/internal/alert"
+)
+
+// notifierFunc adapts a function to alert.Notifier
+type notifierFunc func(alert.Alert) error
+
+func (f notifierFunc) Notify(a alert.Alert) error { return f(a) }
+
+func TestInstrumentNotifier(t *testing.T) {
+	m := New(nil, nil)
+	failing := true
+	notifier := m.InstrumentNotifier("webhook", notifierFunc(func(alert.Alert) error {
+		if failing {
+			return errors.New("status 500")
+		}
+		return nil
+	}))
+
+	if err := notifier.Notify(alert.Alert{Kind: alert.KindDown}); err == nil {
+		t.Error("the wrapped notifier's error was swallowed")
+	}
+	failing = false
+	for range 2 {
+		if err := notifier.Notify(alert.Alert{Kind: alert.KindUp}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := testutil.ToFloat64(m.notifications.WithLabelValues("webhook", "failure")); got != 1 {
+		t.Errorf("failures = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.notifications.WithLabelValues("webhook", "success")); got != 2 {
+		t.Errorf("successes = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(m.notifyDuration, "monitrix_notify_duration_seconds"); got != 1 {
+		t.Errorf("got %d duration series, want 1 for the webhook", got)
+	}
+}
@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckMode combines the checks of a host with several
+type CheckMode string
+
+const (
+	CheckAll CheckMode = "all" // up only if every check succeeds
+	CheckAny CheckMode = "any" // up if any check succeeds
+)
+
+// HostChecks are the checks of one host and how they're combined
+type HostChecks struct {
+	Checks []PingMode
+	Mode   CheckMode
+}
+
+// CheckResult reports the outcome of one of a host's checks
+type CheckResult struct {
+	Mode       PingMode  `json:"mode"`
+	Success    bool      `json:"success"`
+	Latency    int64     `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+	ErrorCode  ErrorCode `json:"error_code,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+}
+
+// ParseHostChecks parses a host's checks: modes joined with "+" must all
+// succeed, as in "tcp+http" for an open port that also serves a healthy
+// response, while modes joined with "|" need only one, as in "icmp|tcp"
+func ParseHostChecks(spec string) (HostChecks, error) {
+	separator, mode := "+", CheckAll
+	if strings.Contains(spec, "|") {
+		if strings.Contains(spec, "+") {
+			return HostChecks{}, fmt.Errorf("can't mix + and | in %q", spec)
+		}
+		separator, mode = "|", CheckAny
+	}
+
+	checks := HostChecks{Mode: mode}
+	for _, field := range strings.Split(spec, separator) {
+		check := PingMode(strings.TrimSpace(field))
+		if !ValidPingMode(check) {
+			return HostChecks{}, fmt.Errorf("unknown check %q, expected tcp, icmp or http", field)
+		}
+		checks.Checks = append(checks.Checks, check)
+	}
+	return checks, nil
+}
+
+// probeChecks runs every check of a host in turn and combines them, with
+// each check recorded in Checks. The rest of the result is the first
+// successful check's when the host is up, and otherwise the first failed
+// check's under CheckAll or the last under CheckAny.
+func (m *Monitor) probeChecks(host string, checks HostChecks) PingResult {
+	var outcomes []CheckResult
+	var passed, failed *PingResult
+	var failedMode PingMode
+	for _, mode := range checks.Checks {
+		check := m.probeMode(host, mode)
+		outcomes = append(outcomes, CheckResult{
+			Mode:       mode,
+			Success:    check.Success,
+			Latency:    check.Latency,
+			Error:      check.Error,
+			ErrorCode:  check.ErrorCode,
+			StatusCode: check.StatusCode,
+		})
+		switch {
+		case check.Success:
+			if passed == nil {
+				passed = &check
+			}
+		case failed == nil || checks.Mode == CheckAny:
+			failed, failedMode = &check, mode
+		}
+	}
+
+	var result PingResult
+	if failed == nil || (checks.Mode == CheckAny && passed != nil) {
+		result = *passed
+	} else {
+		result = *failed
+		result.Error = fmt.Sprintf("%s check failed: %s", failedMode, failed.Error)
+	}
+	result.Checks = outcomes
+	return result
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHostChecks(t *testing.T) {
+	tests := []struct {
+		spec   string
+		want   HostChecks
+		errors bool
+	}{
+		{"tcp+http", HostChecks{Checks: []PingMode{ModeTCP, ModeHTTP}, Mode: CheckAll}, false},
+		{"icmp | tcp", HostChecks{Checks: []PingMode{ModeICMP, ModeTCP}, Mode: CheckAny}, false},
+		{"http", HostChecks{Checks: []PingMode{ModeHTTP}, Mode: CheckAll}, false},
+		{"tcp+http|icmp", HostChecks{}, true},
+		{"tcp+ftp", HostChecks{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			got, err := ParseHostChecks(test.spec)
+			if (err != nil) != test.errors {
+				t.Fatalf("error = %v, want error %v", err, test.errors)
+			}
+			if got.Mode != test.want.Mode || !slices.Equal(got.Checks, test.want.Checks) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHostChecksCombine(t *testing.T) {
+	// The TCP check reaches the listener, while the HTTP check's
+	// https://127.0.0.1/ has nothing on port 443: the port is open but the
+	// application isn't answering
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	tests := []struct {
+		spec string
+		want bool
+	}{
+		{"tcp+http", false},
+		{"tcp|http", true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			checks, err := ParseHostChecks(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := NewMonitor([]string{"127.0.0.1"}, time.Minute, time.Second)
+			m.Output = io.Discard
+			m.Ports = []string{port}
+			m.HostChecks = map[string]HostChecks{"127.0.0.1": checks}
+
+			result := m.Ping("127.0.0.1")
+			if result.Success != test.want {
+				t.Errorf("success = %v (%s), want %v", result.Success, result.Error, test.want)
+			}
+			if !test.want && !strings.HasPrefix(result.Error, "http check failed") {
+				t.Errorf("error = %q, want the HTTP check's failure", result.Error)
+			}
+			if len(result.Checks) != 2 || !result.Checks[0].Success || result.Checks[1].Success {
+				t.Errorf("checks = %+v, want tcp succeeding and http failing", result.Checks)
+			}
+		})
+	}
+}
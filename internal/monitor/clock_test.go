@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"monitrix/internal/clock"
+)
+
+func TestWarmupGraceFollowsClock(t *testing.T) {
+	fake := clock.NewFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	m := NewMonitor([]string{"new.example"}, time.Minute, time.Second)
+	m.Clock = fake
+	m.WarmupGrace = 5 * time.Minute
+	m.Restore(State{Hosts: map[string]HostState{}})
+
+	failed := []PingResult{{Host: "new.example", Error: "connection refused"}}
+	m.markWarmingUp(failed)
+	if !failed[0].WarmingUp {
+		t.Fatal("failure within the grace period wasn't marked as warming up")
+	}
+
+	fake.Advance(6 * time.Minute)
+	failed = []PingResult{{Host: "new.example", Error: "connection refused"}}
+	m.markWarmingUp(failed)
+	if failed[0].WarmingUp {
+		t.Fatal("failure after the grace period was still marked as warming up")
+	}
+}
+
+func TestTrackResolutionStampsClockTime(t *testing.T) {
+	fake := clock.NewFakeClock(time.Date(2024, 3, 1, 12, 34, 56, 0, time.UTC))
+	var out bytes.Buffer
+	m := NewMonitor([]string{"typo.example"}, time.Minute, time.Second)
+	m.Clock = fake
+	m.Output = &out
+	m.MisconfiguredAfter = 2
+
+	failed := []PingResult{{Host: "typo.example", Error: "dns lookup failed: no such host"}}
+	m.trackResolution(failed)
+	m.trackResolution(failed)
+
+	if !strings.Contains(out.String(), "[2024-03-01 12:34:56] Warning: typo.example has never resolved") {
+		t.Errorf("warning not stamped with the clock's time: %q", out.String())
+	}
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Probe concurrency bounds. Each probe holds at most one socket, so a
+// quarter of the descriptor limit leaves ample room for log files, HTTP
+// clients and the web server.
+const (
+	fdShare             = 4
+	maxConcurrency      = 256
+	fallbackConcurrency = 16
+)
+
+// DefaultConcurrency derives a safe probe concurrency from the process's file
+// descriptor soft limit. It also returns a description of how the limit was chosen.
+func DefaultConcurrency() (int, string) {
+	limit, ok := fdSoftLimit()
+	if !ok {
+		return fallbackConcurrency, "file descriptor limit unavailable"
+	}
+
+	concurrency := limit / fdShare
+	if concurrency > maxConcurrency {
+		concurrency = maxConcurrency
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return int(concurrency), fmt.Sprintf("1/%d of file descriptor limit %d", fdShare, limit)
+}
+
+// probeHosts pings every host with at most MaxConcurrency probes in flight,
+// returning results in host order
+func (m *Monitor) probeHosts(ctx context.Context, hosts []string) []PingResult {
+	hosts = m.cycleHosts(hosts)
+	results := make([]PingResult, len(hosts))
+
+	if m.MaxConcurrency <= 1 {
+		for i, host := range hosts {
+			results[i] = m.tracedPing(ctx, host)
+		}
+		return results
+	}
+
+	sem := make(chan struct{}, m.MaxConcurrency)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, host string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = m.tracedPing(ctx, host)
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
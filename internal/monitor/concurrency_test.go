@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPingAllProbesSlowHostsConcurrently(t *testing.T) {
+	// A server that never answers, so every probe runs into the timeout
+	stall := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stall:
+		}
+	}))
+	defer server.Close()
+	defer close(stall)
+
+	const timeout = 300 * time.Millisecond
+	hosts := make([]string, 10)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("%s/host%d", server.URL, i)
+	}
+	m := NewMonitor(hosts, time.Minute, timeout)
+	m.Output = io.Discard
+	m.MaxConcurrency = len(hosts)
+
+	start := time.Now()
+	results := m.PingAll()
+	elapsed := time.Since(start)
+
+	if len(results) != len(hosts) {
+		t.Fatalf("got %d results, want %d", len(results), len(hosts))
+	}
+	for _, result := range results {
+		if result.Success || result.Code() != CodeTimeout {
+			t.Errorf("%s: success=%v code=%q, want a timeout", result.Host, result.Success, result.Code())
+		}
+	}
+	// Probed one after another they would take ten timeouts
+	if elapsed > 3*timeout {
+		t.Errorf("10 stalled hosts took %v, want about one %v timeout", elapsed, timeout)
+	}
+}
@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// dnsPrefix marks a host spec as a DNS record probe, e.g. "dns:MX:example.com"
+const dnsPrefix = "dns:"
+
+// maxRecords bounds how many answers are kept on a result
+const maxRecords = 10
+
+// dnsRecordTypes are the record types a DNS probe can query
+var dnsRecordTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "MX": true, "NS": true, "TXT": true,
+}
+
+// ParseDNSTarget splits a "dns:TYPE:name" host spec. ok is false for
+// ordinary hosts; err is set for malformed DNS specs.
+func ParseDNSTarget(host string) (recordType, name string, ok bool, err error) {
+	spec, found := strings.CutPrefix(host, dnsPrefix)
+	if !found {
+		return "", "", false, nil
+	}
+	recordType, name, found = strings.Cut(spec, ":")
+	recordType = strings.ToUpper(recordType)
+	if !found || name == "" {
+		return "", "", true, fmt.Errorf("expected dns:TYPE:name, got %q", host)
+	}
+	if !dnsRecordTypes[recordType] {
+		return "", "", true, fmt.Errorf("unsupported DNS record type %q", recordType)
+	}
+	return recordType, name, true, nil
+}
+
+// resolver returns the resolver used for lookups, honouring DNSServer
+func (m *Monitor) resolver() *net.Resolver {
+	if m.DNSServer == "" {
+		return &net.Resolver{}
+	}
+	server := m.DNSServer
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// probeDNS queries a record type for name and succeeds when any answer is returned
+func (m *Monitor) probeDNS(host, recordType, name string) PingResult {
+	start := time.Now()
+	result := PingResult{
+		Host:      host,
+		Timestamp: start,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeoutFor(host))
+	defer cancel()
+
+	records, err := lookupRecords(ctx, m.resolver(), recordType, name)
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = fmt.Sprintf("DNS %s lookup failed: %v", recordType, err)
+		result.ErrorCode = CodeDNS
+		return result
+	}
+	if len(records) == 0 {
+		result.Error = fmt.Sprintf("no %s records for %s", recordType, name)
+		result.ErrorCode = CodeNoAddress
+		return result
+	}
+
+	if len(records) > maxRecords {
+		records = records[:maxRecords]
+	}
+	result.Records = records
+	result.Success = true
+	m.checkLatency(&result, time.Since(start))
+	return result
+}
+
+// lookupRecords returns the answers for one record type as strings
+func lookupRecords(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]string, len(ips))
+		for i, ip := range ips {
+			records[i] = ip.String()
+		}
+		return records, nil
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		// LookupCNAME returns the name itself when there is no CNAME
+		if strings.TrimSuffix(cname, ".") == strings.TrimSuffix(name, ".") {
+			return nil, nil
+		}
+		return []string{cname}, nil
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]string, len(mxs))
+		for i, mx := range mxs {
+			records[i] = fmt.Sprintf("%d %s", mx.Pref, mx.Host)
+		}
+		return records, nil
+	case "NS":
+		nss, err := resolver.LookupNS(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]string, len(nss))
+		for i, ns := range nss {
+			records[i] = ns.Host
+		}
+		return records, nil
+	case "TXT":
+		return resolver.LookupTXT(ctx, name)
+	}
+	return nil, fmt.Errorf("unsupported DNS record type %q", recordType)
+}
@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// dialOptions are the per-host settings applied to a probe connection
+type dialOptions struct {
+	timeout time.Duration
+	dscp    int       // zero leaves the socket's default marking
+	ports   []string  // TCP ports tried, in order
+	output  io.Writer // receives warnings, the monitor's console output
+}
+
+// dialOptionsFor returns the dial settings configured for a host
+func (m *Monitor) dialOptionsFor(host string) dialOptions {
+	opts := dialOptions{timeout: m.timeoutFor(host), dscp: m.DSCP, ports: m.portsFor(host), output: m.output()}
+	if dscp, ok := m.HostDSCP[host]; ok {
+		opts.dscp = dscp
+	}
+	return opts
+}
+
+// ParseDSCP parses a DSCP codepoint given as a number (0-63) or a class
+// name such as "ef", "af41" or "cs1"
+func ParseDSCP(value string) (int, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case name == "ef":
+		return 46, nil
+	case len(name) == 4 && strings.HasPrefix(name, "af") &&
+		name[2] >= '1' && name[2] <= '4' && name[3] >= '1' && name[3] <= '3':
+		return int(name[2]-'0')*8 + int(name[3]-'0')*2, nil
+	case len(name) == 3 && strings.HasPrefix(name, "cs") && name[2] >= '0' && name[2] <= '7':
+		return int(name[2]-'0') * 8, nil
+	}
+	dscp, err := strconv.Atoi(name)
+	if err != nil || dscp < 0 || dscp > 63 {
+		return 0, fmt.Errorf("invalid DSCP %q, expected 0-63 or a class like ef, af41 or cs1", value)
+	}
+	return dscp, nil
+}
+
+// markWarning makes sure an unsupported marking is reported only once
+var markWarning sync.Once
+
+// markControl returns a dialer Control function setting the DSCP bits of
+// the socket's TOS/traffic class before it connects, or nil when opts.dscp
+// is zero. Where marking isn't permitted the probe goes out unmarked, with
+// a one-time warning on opts.output.
+func markControl(opts dialOptions) func(network, address string, c syscall.RawConn) error {
+	if opts.dscp == 0 {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		if err := setTOS(network, c, opts.dscp<<2); err != nil {
+			warnUnmarked(opts, err)
+		}
+		return nil
+	}
+}
+
+// warnUnmarked reports, once, that probes can't be marked
+func warnUnmarked(opts dialOptions, err error) {
+	markWarning.Do(func() {
+		fmt.Fprintf(opts.output, "Warning: can't set DSCP %d on probe sockets, probing unmarked: %v\n", opts.dscp, err)
+	})
+}
+
+// recordDSCP stores the DSCP actually set on conn when marking was requested
+func recordDSCP(result *PingResult, conn net.Conn, dscp int) {
+	if dscp == 0 {
+		return
+	}
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return
+	}
+
+	var tos int
+	var err error
+	if addr.IP.To4() != nil {
+		tos, err = ipv4.NewConn(conn).TOS()
+	} else {
+		tos, err = ipv6.NewConn(conn).TrafficClass()
+	}
+	if err == nil {
+		result.DSCP = tos >> 2
+	}
+}
+
+// chainControl runs each non-nil Control function in turn
+func chainControl(fns ...func(network, address string, c syscall.RawConn) error) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			if err := fn(network, address, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
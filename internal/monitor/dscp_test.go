@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// refusingConn is a socket whose options can't be changed
+type refusingConn struct{}
+
+func (refusingConn) Control(func(fd uintptr)) error    { return errors.New("operation not permitted") }
+func (refusingConn) Read(func(fd uintptr) bool) error  { return nil }
+func (refusingConn) Write(func(fd uintptr) bool) error { return nil }
+
+func TestMarkControlWarnsOnMonitorOutput(t *testing.T) {
+	markWarning = sync.Once{}
+	t.Cleanup(func() { markWarning = sync.Once{} })
+
+	var out bytes.Buffer
+	m := NewMonitor([]string{"example.com"}, time.Minute, time.Second)
+	m.Output = &out
+	m.DSCP = 46
+
+	control := markControl(m.dialOptionsFor("example.com"))
+	for range 2 {
+		if err := control("tcp4", "192.0.2.1:443", refusingConn{}); err != nil {
+			t.Fatalf("unmarkable socket failed the dial: %v", err)
+		}
+	}
+	if got := strings.Count(out.String(), "can't set DSCP 46"); got != 1 {
+		t.Errorf("got %d warnings on the monitor output, want 1: %q", got, out.String())
+	}
+}
+
+func TestMarkControlUnsetDSCP(t *testing.T) {
+	m := NewMonitor([]string{"example.com"}, time.Minute, time.Second)
+	if markControl(m.dialOptionsFor("example.com")) != nil {
+		t.Error("markControl without a DSCP returned a Control function")
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ErrorCode classifies why a probe failed
+type ErrorCode string
+
+const (
+	CodeInvalidHost  ErrorCode = "invalid_host"       // the host couldn't be parsed or converted
+	CodeDNS          ErrorCode = "dns_failure"        // resolution failed
+	CodeNoAddress    ErrorCode = "no_address"         // resolved, but to no usable address
+	CodeRefused      ErrorCode = "connection_refused" // the target actively refused
+	CodeTimeout      ErrorCode = "timeout"            // no answer within the timeout
+	CodeUnreachable  ErrorCode = "unreachable"        // no route to the host or network
+	CodeConnect      ErrorCode = "connect_failure"    // any other connection error
+	CodePacketLoss   ErrorCode = "packet_loss"        // some probes answered, but loss reached FailureLoss
+	CodeProxy        ErrorCode = "proxy"              // the proxy tunnel couldn't be established
+	CodeHTTPStatus   ErrorCode = "http_status"        // an HTTP check got a status other than 2xx/3xx
+	CodeBodyMismatch ErrorCode = "body_mismatch"      // an HTTP check's body lacked the expected text
+	CodePanic        ErrorCode = "panic"              // the probe panicked
+	CodePlugin       ErrorCode = "plugin"             // a probe plugin failed to run or answer per its contract
+	CodeSimulated    ErrorCode = "simulated"          // fabricated by a simulation scenario
+	CodeUnknown      ErrorCode = "unknown"            // an older result whose error isn't recognised
+)
+
+// IsDNS reports whether the code is a name resolution failure
+func (c ErrorCode) IsDNS() bool {
+	return c == CodeDNS || c == CodeNoAddress
+}
+
+// IsConnect reports whether the code is a failure to connect to a resolved address
+func (c ErrorCode) IsConnect() bool {
+	switch c {
+	case CodeRefused, CodeTimeout, CodeUnreachable, CodeConnect, CodePacketLoss:
+		return true
+	}
+	return false
+}
+
+// Code returns the failure's error code, or "" for a successful or paused
+// result. Results stored before codes existed are classified from their
+// error text.
+func (r PingResult) Code() ErrorCode {
+	if r.Success || r.Paused {
+		return ""
+	}
+	if r.ErrorCode != "" {
+		return r.ErrorCode
+	}
+
+	text := strings.ToLower(r.Error)
+	switch {
+	case r.Simulated:
+		return CodeSimulated
+	case r.ProxyError:
+		return CodeProxy
+	case strings.HasPrefix(text, "dns "), strings.Contains(text, "no such host"):
+		return CodeDNS
+	case strings.HasPrefix(text, "no ip addresses"), strings.HasPrefix(text, "no address for family"):
+		return CodeNoAddress
+	case strings.Contains(text, "connection refused"):
+		return CodeRefused
+	case strings.Contains(text, "timeout"), strings.Contains(text, "timed out"):
+		return CodeTimeout
+	case strings.Contains(text, "unreachable"):
+		return CodeUnreachable
+	}
+	return CodeUnknown
+}
+
+// connectErrorCode classifies an error returned by dial
+func connectErrorCode(err error) ErrorCode {
+	var netErr net.Error
+	switch {
+	case errors.As(err, new(*ProxyError)):
+		return CodeProxy
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return CodeRefused
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return CodeTimeout
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return CodeUnreachable
+	}
+	return CodeConnect
+}
@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Probe networks selecting the address family
+const (
+	NetworkAuto = "tcp"  // let the OS choose
+	NetworkIPv4 = "tcp4" // IPv4 only
+	NetworkIPv6 = "tcp6" // IPv6 only
+	NetworkDual = "dual" // probe IPv4 and IPv6 separately and report both
+)
+
+// FamilyResult reports the outcome for one address family in dual mode
+type FamilyResult struct {
+	Network string `json:"network"`
+	Success bool   `json:"success"`
+	Latency int64  `json:"latency_ms"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ValidNetwork reports whether network is a supported probe network
+func ValidNetwork(network string) bool {
+	switch network {
+	case NetworkAuto, NetworkIPv4, NetworkIPv6, NetworkDual:
+		return true
+	}
+	return false
+}
+
+// timeoutFor returns the probe timeout configured for a host
+func (m *Monitor) timeoutFor(host string) time.Duration {
+	if timeout, ok := m.HostTimeouts[host]; ok && timeout > 0 {
+		return timeout
+	}
+	return m.timeout
+}
+
+// networkFor returns the probe network configured for a host
+func (m *Monitor) networkFor(host string) string {
+	if network, ok := m.HostNetworks[host]; ok && network != "" {
+		return network
+	}
+	if m.Network != "" {
+		return m.Network
+	}
+	return NetworkAuto
+}
+
+// hasFamily reports whether any resolved address belongs to the network's family
+func hasFamily(addrs []string, network string) bool {
+	if network == NetworkAuto {
+		return len(addrs) > 0
+	}
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		isIPv4 := ip.To4() != nil
+		if isIPv4 == (network == NetworkIPv4) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipVersion returns 4 or 6 for ip, or 0 when it is nil
+func ipVersion(ip net.IP) int {
+	switch {
+	case ip == nil:
+		return 0
+	case ip.To4() != nil:
+		return 4
+	default:
+		return 6
+	}
+}
+
+// recordIPVersion stores the family of the address conn reached. Through a
+// proxy the remote end is the proxy, so nothing is recorded.
+func (m *Monitor) recordIPVersion(result *PingResult, conn net.Conn) {
+	if m.Proxy != nil {
+		return
+	}
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		result.IPVersion = ipVersion(addr.IP)
+	}
+}
+
+// errNoFamilyAddress builds the error reported when a host has no address in the requested family
+func errNoFamilyAddress(network string) error {
+	return fmt.Errorf("no address for family %s", network)
+}
+
+// probeFamilies probes both address families of an already-resolved host
+func (m *Monitor) probeFamilies(host string, addrs []string, opts dialOptions) []FamilyResult {
+	families := make([]FamilyResult, 0, 2)
+	for _, network := range []string{NetworkIPv4, NetworkIPv6} {
+		family := FamilyResult{Network: network}
+
+		if !hasFamily(addrs, network) {
+			family.Error = errNoFamilyAddress(network).Error()
+			families = append(families, family)
+			continue
+		}
+
+		var lastErr error
+		for _, port := range opts.ports {
+			dialStart := time.Now()
+			conn, err := m.dial(network, net.JoinHostPort(host, port), opts)
+			family.Latency = time.Since(dialStart).Milliseconds()
+			if err == nil {
+				conn.Close()
+				family.Success = true
+				lastErr = nil
+				break
+			}
+			lastErr = err
+		}
+		if lastErr != nil {
+			family.Error = lastErr.Error()
+		}
+		families = append(families, family)
+	}
+	return families
+}
+
+// familiesError summarises the failures of a dual-stack probe
+func familiesError(families []FamilyResult) error {
+	var errs []string
+	for _, family := range families {
+		if family.Error != "" {
+			errs = append(errs, family.Network+": "+family.Error)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package monitor
+
+// fdSoftLimit reports that the descriptor limit can't be queried on this platform
+func fdSoftLimit() (uint64, bool) {
+	return 0, false
+}
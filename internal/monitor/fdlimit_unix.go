@@ -0,0 +1,14 @@
+//go:build unix
+
+package monitor
+
+import "golang.org/x/sys/unix"
+
+// fdSoftLimit returns the process's soft limit on open file descriptors
+func fdSoftLimit() (uint64, bool) {
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, false
+	}
+	return uint64(limit.Cur), true
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import "time"
+
+// Defaults used by NewMonitorWithConfigs for hosts that don't set them
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+// HostConfig holds the monitoring settings of one host; zero fields use
+// the monitor-wide value
+type HostConfig struct {
+	Host     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Ports    []string
+}
+
+// NewMonitorWithConfigs creates a monitor whose hosts each have their own
+// interval, timeout and ports. Hosts that leave them unset use
+// DefaultInterval, DefaultTimeout and the default ports.
+func NewMonitorWithConfigs(configs []HostConfig) *Monitor {
+	hosts := make([]string, 0, len(configs))
+	for _, config := range configs {
+		hosts = append(hosts, config.Host)
+	}
+
+	m := NewMonitor(hosts, DefaultInterval, DefaultTimeout)
+	m.HostIntervals = make(map[string]time.Duration)
+	m.HostTimeouts = make(map[string]time.Duration)
+	m.HostPorts = make(map[string][]string)
+	for _, config := range configs {
+		if config.Interval > 0 {
+			m.HostIntervals[config.Host] = config.Interval
+		}
+		if config.Timeout > 0 {
+			m.HostTimeouts[config.Host] = config.Timeout
+		}
+		if len(config.Ports) > 0 {
+			m.HostPorts[config.Host] = config.Ports
+		}
+	}
+	return m
+}
+
+// intervalFor returns the probe interval configured for a host
+func (m *Monitor) intervalFor(host string) time.Duration {
+	if interval, ok := m.HostIntervals[host]; ok && interval > 0 {
+		return interval
+	}
+	return m.interval
+}
+
+// ShortestInterval returns the most frequent probe interval across hosts,
+// so some cycle completes at least this often
+func (m *Monitor) ShortestInterval() time.Duration {
+	groups := m.hostGroups()
+	shortest := groups[0].interval
+	for _, group := range groups {
+		shortest = min(shortest, group.interval)
+	}
+	return shortest
+}
+
+// LongestInterval returns the least frequent probe interval across hosts,
+// so every host is probed at least this often
+func (m *Monitor) LongestInterval() time.Duration {
+	groups := m.hostGroups()
+	longest := groups[0].interval
+	for _, group := range groups {
+		longest = max(longest, group.interval)
+	}
+	return longest
+}
+
+// hostGroup is a set of hosts probed together on one ticker
+type hostGroup struct {
+	interval time.Duration
+	hosts    []string
+}
+
+// hostGroups gives every host its own ticker at its interval. A cycle
+// then holds one host, and up/down is decided on the latest result of
+// every host (see LatestResults).
+func (m *Monitor) hostGroups() []hostGroup {
+	groups := make([]hostGroup, 0, len(m.hosts))
+	for _, host := range m.hosts {
+		groups = append(groups, hostGroup{interval: m.intervalFor(host), hosts: []string{host}})
+	}
+	if len(groups) == 0 {
+		groups = append(groups, hostGroup{interval: m.interval})
+	}
+	return groups
+}
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxHTTPBody bounds how much of a response body is searched for ExpectBody
+const maxHTTPBody = 1 << 20
+
+// ParseHTTPTarget reports whether a host spec is an HTTP health-check URL
+// such as "https://example.com/health"; err is set for malformed URLs.
+func ParseHTTPTarget(host string) (target *url.URL, ok bool, err error) {
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		return nil, false, nil
+	}
+	target, err = url.Parse(host)
+	if err != nil {
+		return nil, true, err
+	}
+	if target.Hostname() == "" {
+		return nil, true, fmt.Errorf("no host in %q", host)
+	}
+	return target, true, nil
+}
+
+// expectBodyFor returns the substring a host's response body must contain
+func (m *Monitor) expectBodyFor(host string) string {
+	if expect, ok := m.HostExpectBody[host]; ok {
+		return expect
+	}
+	return m.ExpectBody
+}
+
+// ProbeHTTP checks the health of an http or https URL with a GET request
+func (m *Monitor) ProbeHTTP(target string) PingResult {
+	return m.probeHTTP(target, target)
+}
+
+// probeHTTP GETs target on behalf of host. 2xx and 3xx responses succeed
+// (redirects aren't followed) if the body contains the expected substring;
+// other statuses fail with the status line as the error. Latency runs
+// until the response headers, or the end of the body when it is checked.
+func (m *Monitor) probeHTTP(host, target string) PingResult {
+	start := time.Now()
+	result := PingResult{Host: host, Timestamp: start, Mode: ModeHTTP}
+	fail := func(code ErrorCode, format string, args ...any) PingResult {
+		result.Success = false
+		result.Error = fmt.Sprintf(format, args...)
+		result.ErrorCode = code
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+
+	opts := m.dialOptionsFor(host)
+	network := m.networkFor(host)
+	if network == NetworkDual {
+		network = NetworkAuto
+	}
+	dial := func(ctx context.Context, _, address string) (net.Conn, error) {
+		return m.dial(network, address, opts)
+	}
+	transport := &http.Transport{
+		DialContext:        dial,
+		DisableKeepAlives:  true,
+		DisableCompression: true,
+	}
+	ctx := context.Background()
+	if m.WarmConnections {
+		transport = m.warmTransport(host, dial)
+		ctx = traceConnection(ctx, &result)
+	}
+	client := &http.Client{
+		Timeout:   opts.timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fail(CodeInvalidHost, "%v", err)
+	}
+	req.Header.Set("User-Agent", "monitrix")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var dnsErr *net.DNSError
+		var netErr net.Error
+		switch {
+		case errors.As(err, &dnsErr):
+			return fail(CodeDNS, "DNS lookup failed: %v", dnsErr)
+		case errors.As(err, &netErr) && netErr.Timeout():
+			return fail(CodeTimeout, "no response within %v", opts.timeout)
+		}
+		return fail(connectErrorCode(err), "%v", err)
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fail(CodeHTTPStatus, "%s %s", resp.Proto, resp.Status)
+	}
+	if expect := m.expectBodyFor(host); expect != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+		if err != nil {
+			return fail(connectErrorCode(err), "reading body: %v", err)
+		}
+		if !strings.Contains(string(body), expect) {
+			return fail(CodeBodyMismatch, "%s %s, but body doesn't contain %q", resp.Proto, resp.Status, expect)
+		}
+	}
+
+	elapsed := time.Since(start)
+	result.Success = true
+	result.Latency = elapsed.Milliseconds()
+	m.checkLatency(&result, elapsed)
+
+	// A pooled connection is only reused once its response is read to the end
+	if m.WarmConnections {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPBody))
+	}
+	return result
+}
@@ -0,0 +1,261 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// PingMode selects how hosts are probed
+type PingMode string
+
+const (
+	ModeTCP  PingMode = "tcp"  // TCP connect to Ports (default)
+	ModeICMP PingMode = "icmp" // ICMP echo request
+	ModeHTTP PingMode = "http" // GET https://host/, see ProbeHTTP
+)
+
+// ValidPingMode reports whether mode is a known ping mode
+func ValidPingMode(mode PingMode) bool {
+	return mode == ModeTCP || mode == ModeICMP || mode == ModeHTTP
+}
+
+// ICMP protocol numbers, as used by icmp.ParseMessage
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// icmpPayload is sent in every echo request
+var icmpPayload = []byte("monitrix-probe")
+
+// icmpSeq numbers echo requests so replies can be matched to them
+var icmpSeq atomic.Uint32
+
+// icmpWarning makes sure missing ICMP permissions are reported only once
+var icmpWarning sync.Once
+
+// errICMPUnavailable is returned when no ICMP socket can be opened,
+// typically for lack of raw-socket privileges
+type errICMPUnavailable struct{ err error }
+
+func (e *errICMPUnavailable) Error() string {
+	return fmt.Sprintf("ICMP unavailable: %v", e.err)
+}
+
+func (e *errICMPUnavailable) Unwrap() error { return e.err }
+
+// echo probes host (the ASCII name) with an ICMP echo request to its first
+// address in network and completes result with the round trip. Without
+// permission to open an ICMP socket it falls back to a TCP connect and
+// notes why in Error.
+func (m *Monitor) echo(host, network string, addrs []string, result PingResult) PingResult {
+	ip := firstAddress(addrs, network)
+	opts := m.dialOptionsFor(result.Host)
+
+	rtt, marked, err := pingICMP(ip, opts)
+	if unavailable := (*errICMPUnavailable)(nil); errors.As(err, &unavailable) {
+		icmpWarning.Do(func() {
+			fmt.Fprintf(m.output(), "Warning: %v; probing with TCP instead (needs CAP_NET_RAW or net.ipv4.ping_group_range)\n", unavailable.err)
+		})
+		fallback := m.connect(host, network, result.Timestamp, result)
+		fallback.Error = joinErrors(fallback.Error, unavailable.Error()+", fell back to TCP")
+		return fallback
+	}
+
+	result.Mode = ModeICMP
+	if err != nil {
+		var unreachable *errDestinationUnreachable
+		result.Success = false
+		result.Error = err.Error()
+		result.Latency = time.Since(result.Timestamp).Milliseconds()
+		switch {
+		case errors.As(err, &unreachable):
+			result.ErrorCode = CodeUnreachable
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			result.Error = fmt.Sprintf("no echo reply within %v", opts.timeout)
+			result.ErrorCode = CodeTimeout
+		default:
+			result.ErrorCode = connectErrorCode(err)
+		}
+		return result
+	}
+
+	result.Success = true
+	result.Latency = rtt.Milliseconds()
+	result.ConnectLatency = rtt.Milliseconds()
+	result.IPVersion = ipVersion(ip)
+	if marked {
+		result.DSCP = opts.dscp
+	}
+	m.checkLatency(&result, rtt)
+	return result
+}
+
+// joinErrors combines two error messages, either of which may be empty
+func joinErrors(first, second string) string {
+	if first == "" {
+		return second
+	}
+	return first + "; " + second
+}
+
+// firstAddress returns the first of addrs in network, any family for NetworkAuto
+func firstAddress(addrs []string, network string) net.IP {
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		if network == NetworkAuto || (ip.To4() != nil) == (network == NetworkIPv4) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// errDestinationUnreachable is an ICMP destination unreachable answer to an echo
+type errDestinationUnreachable struct{ code int }
+
+func (e *errDestinationUnreachable) Error() string {
+	return fmt.Sprintf("destination unreachable (ICMP code %d)", e.code)
+}
+
+// listenICMP opens an ICMP socket for the family of ip: a raw socket when
+// privileged, otherwise an unprivileged datagram socket where the system
+// allows it (Linux with a matching net.ipv4.ping_group_range, macOS)
+func listenICMP(ip net.IP) (conn *icmp.PacketConn, privileged bool, err error) {
+	rawNetwork, dgramNetwork, listenAddr := "ip4:icmp", "udp4", "0.0.0.0"
+	if ip.To4() == nil {
+		rawNetwork, dgramNetwork, listenAddr = "ip6:ipv6-icmp", "udp6", "::"
+	}
+	if conn, err = icmp.ListenPacket(rawNetwork, listenAddr); err == nil {
+		return conn, true, nil
+	}
+	if conn, dgramErr := icmp.ListenPacket(dgramNetwork, listenAddr); dgramErr == nil {
+		return conn, false, nil
+	}
+	return nil, false, &errICMPUnavailable{err}
+}
+
+// pingICMP sends one echo request to ip and waits for the matching reply
+// until the timeout, returning the round-trip time and whether the request
+// carried the DSCP marking
+func pingICMP(ip net.IP, opts dialOptions) (rtt time.Duration, marked bool, err error) {
+	conn, privileged, err := listenICMP(ip)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+
+	isIPv4 := ip.To4() != nil
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	protocol := protocolICMP
+	if !isIPv4 {
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		protocol = protocolIPv6ICMP
+	}
+	if opts.dscp != 0 {
+		marked = markICMP(conn, isIPv4, opts)
+	}
+
+	// Datagram sockets are addressed by UDP address, and the kernel replaces
+	// the echo ID with its own, so only the sequence number can be matched
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if !privileged {
+		dst = &net.UDPAddr{IP: ip}
+	}
+	id := os.Getpid() & 0xffff
+	seq := int(icmpSeq.Add(1) & 0xffff)
+	request, err := (&icmp.Message{
+		Type: echoType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: icmpPayload},
+	}).Marshal(nil)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(opts.timeout)); err != nil {
+		return 0, false, err
+	}
+	sent := time.Now()
+	if _, err := conn.WriteTo(request, dst); err != nil {
+		return 0, false, err
+	}
+
+	// A raw socket sees every ICMP packet to this host, so anything that
+	// isn't the answer to this request is skipped
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, false, err
+		}
+		received := time.Since(sent)
+		reply, err := icmp.ParseMessage(protocol, buf[:n])
+		if err != nil {
+			continue
+		}
+		switch body := reply.Body.(type) {
+		case *icmp.Echo:
+			if reply.Type == replyType && body.Seq == seq && (!privileged || body.ID == id) && peerIP(peer).Equal(ip) {
+				return received, marked, nil
+			}
+		case *icmp.DstUnreach:
+			if quotedSeq(body.Data, isIPv4) == seq {
+				return 0, false, &errDestinationUnreachable{reply.Code}
+			}
+		}
+	}
+}
+
+// peerIP returns the IP of a packet source from either kind of ICMP socket
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+// quotedSeq extracts the echo sequence number from the original datagram
+// quoted in an ICMP error, or -1 when it can't be read
+func quotedSeq(data []byte, isIPv4 bool) int {
+	headerLen := 40
+	if isIPv4 {
+		if len(data) == 0 {
+			return -1
+		}
+		headerLen = int(data[0]&0x0f) * 4
+	}
+	if len(data) < headerLen+8 {
+		return -1
+	}
+	return int(binary.BigEndian.Uint16(data[headerLen+6:]))
+}
+
+// markICMP sets the DSCP bits on an ICMP socket, warning once where it
+// isn't permitted like markControl does for TCP
+func markICMP(conn *icmp.PacketConn, isIPv4 bool, opts dialOptions) bool {
+	var err error
+	if isIPv4 {
+		err = conn.IPv4PacketConn().SetTOS(opts.dscp << 2)
+	} else {
+		err = conn.IPv6PacketConn().SetTrafficClass(opts.dscp << 2)
+	}
+	if err != nil {
+		warnUnmarked(opts, err)
+	}
+	return err == nil
+}
@@ -0,0 +1,32 @@
+package monitor
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/net/idna"
+)
+
+// ToASCII converts an internationalized hostname such as "münchen.de" to
+// its ASCII (punycode) form for resolution. IP literals and names that are
+// already ASCII are returned unchanged.
+func ToASCII(host string) (string, error) {
+	if isASCII(host) || net.ParseIP(host) != nil {
+		return host, nil
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid internationalized hostname: %w", err)
+	}
+	return ascii, nil
+}
+
+// isASCII reports whether s contains only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import "time"
+
+// LatestResults keeps the most recent result of every host. Each host is
+// probed on its own ticker, so a cycle only holds some of the hosts; it is
+// judged together with the latest results of the others. A host's result
+// expires once it is more than twice as old as the spacing between its last
+// two results, or than Interval while only one has been seen, so a host that
+// is no longer probed, or results from before a long gap, don't stand in for
+// current ones. The zero value is ready to use.
+type LatestResults struct {
+	// Interval is the longest configured probe interval, expiring hosts that
+	// have reported only once; zero keeps them until a second result
+	Interval time.Duration
+
+	hosts   []string
+	latest  map[string]PingResult
+	spacing map[string]time.Duration
+}
+
+// Update folds in a cycle's results and returns the current result of every
+// host, in order of first appearance, as of the newest result
+func (l *LatestResults) Update(results []PingResult) []PingResult {
+	if l.latest == nil {
+		l.latest = make(map[string]PingResult)
+		l.spacing = make(map[string]time.Duration)
+	}
+
+	var now time.Time
+	for _, result := range results {
+		if previous, ok := l.latest[result.Host]; ok {
+			if spacing := result.Timestamp.Sub(previous.Timestamp); spacing > 0 {
+				l.spacing[result.Host] = spacing
+			}
+		} else {
+			l.hosts = append(l.hosts, result.Host)
+		}
+		l.latest[result.Host] = result
+		if result.Timestamp.After(now) {
+			now = result.Timestamp
+		}
+	}
+	return l.Current(now)
+}
+
+// Current returns the result of every host that hasn't expired by now, in
+// order of first appearance
+func (l *LatestResults) Current(now time.Time) []PingResult {
+	current := make([]PingResult, 0, len(l.hosts))
+	hosts := l.hosts[:0]
+	for _, host := range l.hosts {
+		result := l.latest[host]
+		spacing, ok := l.spacing[host]
+		if !ok {
+			spacing = l.Interval
+		}
+		if spacing > 0 && now.Sub(result.Timestamp) > 2*spacing {
+			delete(l.latest, host)
+			delete(l.spacing, host)
+			continue
+		}
+		hosts = append(hosts, host)
+		current = append(current, result)
+	}
+	l.hosts = hosts
+	return current
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestResultsExpiry(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(host string, offset time.Duration) PingResult {
+		return PingResult{Host: host, Success: true, Timestamp: start.Add(offset)}
+	}
+
+	t.Run("single result expires by the interval", func(t *testing.T) {
+		latest := LatestResults{Interval: 30 * time.Second}
+		latest.Update([]PingResult{at("once", 0)})
+		if got := latest.Update([]PingResult{at("a", 50*time.Second)}); len(got) != 2 {
+			t.Errorf("got %d results within two intervals, want 2", len(got))
+		}
+		got := latest.Update([]PingResult{at("a", 61*time.Second)})
+		if len(got) != 1 || got[0].Host != "a" {
+			t.Errorf("got %+v, want only a once the single result is two intervals old", got)
+		}
+	})
+
+	t.Run("single result kept without an interval", func(t *testing.T) {
+		var latest LatestResults
+		latest.Update([]PingResult{at("once", 0)})
+		if got := latest.Update([]PingResult{at("a", time.Hour)}); len(got) != 2 {
+			t.Errorf("got %d results, want 2", len(got))
+		}
+	})
+
+	t.Run("measured spacing overrides the interval", func(t *testing.T) {
+		latest := LatestResults{Interval: 30 * time.Second}
+		latest.Update([]PingResult{at("slow", 0)})
+		latest.Update([]PingResult{at("slow", 5*time.Minute)})
+		if got := latest.Update([]PingResult{at("a", 10*time.Minute)}); len(got) != 2 {
+			t.Errorf("got %d results, want slow kept within twice its spacing", len(got))
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+)
+
+// check makes one check of host: a single probe, or ProbesPerCheck probes
+// sent at once and combined into one result
+func (m *Monitor) check(host string) PingResult {
+	if m.ProbesPerCheck <= 1 {
+		return m.pingOnce(host)
+	}
+
+	probes := make([]PingResult, m.ProbesPerCheck)
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probes[i] = m.pingOnce(host)
+		}()
+	}
+	wg.Wait()
+	return m.combineProbes(probes)
+}
+
+// combineProbes reduces a check's probes to one result. It is based on the
+// first successful probe (or the last failure), with the latency averaged
+// over the trusted successes and the loss across all probes.
+func (m *Monitor) combineProbes(probes []PingResult) PingResult {
+	var result PingResult
+	var received int
+	var latencySum, trusted int64
+	for _, probe := range probes {
+		if !probe.Success {
+			continue
+		}
+		if received == 0 {
+			result = probe
+		}
+		received++
+		if probe.LatencyTrusted() {
+			latencySum += probe.Latency
+			trusted++
+		}
+	}
+	if received == 0 {
+		result = probes[len(probes)-1]
+	}
+	if trusted > 0 {
+		result.Latency = latencySum / trusted
+		result.LatencyAnomaly = ""
+	}
+
+	result.ProbesSent = len(probes)
+	result.ProbesReceived = received
+	result.PacketLossPercent = float64(len(probes)-received) / float64(len(probes)) * 100
+
+	if result.Success && result.PacketLossPercent >= m.failureLoss() {
+		result.Success = false
+		result.Error = fmt.Sprintf("%.0f%% packet loss (%d of %d probes answered)", result.PacketLossPercent, received, len(probes))
+		result.ErrorCode = CodePacketLoss
+	}
+	return result
+}
+
+// failureLoss returns the loss percentage at which a check fails
+func (m *Monitor) failureLoss() float64 {
+	if m.FailureLoss <= 0 || m.FailureLoss > 100 {
+		return 100
+	}
+	return m.FailureLoss
+}
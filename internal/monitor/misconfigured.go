@@ -0,0 +1,88 @@
+package monitor
+
+import "fmt"
+
+// resolveTrack follows whether a host has ever resolved
+type resolveTrack struct {
+	resolved      bool // succeeded or got past DNS at least once
+	dnsFailures   int  // consecutive resolution failures
+	misconfigured bool
+	skipped       int // cycles skipped since the last retry
+}
+
+// cycleHosts returns which of hosts to probe this cycle: all of them except
+// those flagged misconfigured that aren't due a retry
+func (m *Monitor) cycleHosts(hosts []string) []string {
+	if m.MisconfiguredAfter <= 0 {
+		return hosts
+	}
+
+	m.resolveMu.Lock()
+	defer m.resolveMu.Unlock()
+
+	probed := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		track := m.resolve[host]
+		if track != nil && track.misconfigured {
+			if m.MisconfiguredRetry <= 0 || track.skipped < m.MisconfiguredRetry-1 {
+				track.skipped++
+				continue
+			}
+			track.skipped = 0
+		}
+		probed = append(probed, host)
+	}
+	return probed
+}
+
+// trackResolution flags hosts that have failed to resolve MisconfiguredAfter
+// times in a row without ever resolving, and clears the flag as soon as
+// one resolves
+func (m *Monitor) trackResolution(results []PingResult) {
+	if m.MisconfiguredAfter <= 0 {
+		return
+	}
+
+	m.resolveMu.Lock()
+	defer m.resolveMu.Unlock()
+
+	now := m.clock().Now().Format("2006-01-02 15:04:05")
+	for _, result := range results {
+		track, ok := m.resolve[result.Host]
+		if !ok {
+			track = &resolveTrack{}
+			m.resolve[result.Host] = track
+		}
+
+		code := result.Code()
+		if !code.IsDNS() && code != CodeInvalidHost {
+			if track.misconfigured {
+				fmt.Fprintf(m.output(), "[%s] %s resolves now; probing it every cycle again\n", now, result.Host)
+			}
+			*track = resolveTrack{resolved: true}
+			continue
+		}
+		if track.resolved || track.misconfigured {
+			continue
+		}
+
+		track.dnsFailures++
+		if track.dnsFailures >= m.MisconfiguredAfter {
+			track.misconfigured = true
+			retry := "it is no longer probed"
+			if m.MisconfiguredRetry > 0 {
+				retry = fmt.Sprintf("it is retried every %d cycles", m.MisconfiguredRetry)
+			}
+			fmt.Fprintf(m.output(), "[%s] Warning: %s has never resolved in %d attempts (%s); treating it as misconfigured, %s\n",
+				now, result.Host, track.dnsFailures, result.Error, retry)
+		}
+	}
+}
+
+// isMisconfigured reports whether host is currently flagged misconfigured
+func (m *Monitor) isMisconfigured(host string) bool {
+	m.resolveMu.Lock()
+	defer m.resolveMu.Unlock()
+	track := m.resolve[host]
+	return track != nil && track.misconfigured
+}
@@ -2,9 +2,22 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"monitrix/internal/clock"
 )
 
 // PingResult represents the result of a ping test
@@ -14,6 +27,111 @@ type PingResult struct {
 	Latency   int64     `json:"latency_ms"` // milliseconds
 	Error     string    `json:"error,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// ErrorCode classifies a failure; older results only have Error, see Code
+	ErrorCode ErrorCode `json:"error_code,omitempty"`
+
+	// DNSLatency is the time spent resolving the host; ConnectLatency is the
+	// successful dial alone, timed from after DNS completed
+	DNSLatency     int64 `json:"dns_latency_ms,omitempty"`
+	ConnectLatency int64 `json:"connect_latency_ms,omitempty"`
+
+	// LatencyAnomaly is set when a successful probe reported an implausible
+	// latency (e.g. from a clock step); such latencies are kept out of aggregates
+	LatencyAnomaly string `json:"latency_anomaly,omitempty"`
+
+	// Paused marks a placeholder written while probing was paused by schedule;
+	// it is neither a success nor a failure
+	Paused bool `json:"paused,omitempty"`
+
+	// Connection is "warm" when an HTTP probe reused the pooled connection
+	// and "cold" when it opened one; set when WarmConnections is enabled
+	Connection string `json:"connection,omitempty"`
+
+	// Network is the probe network when one other than the default was used;
+	// in dual mode Families holds the per-family outcomes
+	Network  string         `json:"network,omitempty"`
+	Families []FamilyResult `json:"families,omitempty"`
+
+	// ProxyError is set when the failure was establishing the proxy tunnel
+	// rather than reaching the target through it
+	ProxyError bool `json:"proxy_error,omitempty"`
+
+	// Addresses is the sorted set the host resolved to, when RecordAddresses is enabled
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Records holds the (bounded) answers of a DNS record probe
+	Records []string `json:"records,omitempty"`
+
+	// Tags are labels added by result transformers, such as "geo" or "session"
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Aggregate is set on roll-up results summarising several cycles;
+	// Latency then holds the average of the trusted latencies
+	Aggregate *ResultAggregate `json:"aggregate,omitempty"`
+
+	// Simulated marks results fabricated by a simulation scenario
+	Simulated bool `json:"simulated,omitempty"`
+
+	// ASCIIHost is the punycode form resolved for an internationalized Host
+	ASCIIHost string `json:"ascii_host,omitempty"`
+
+	// Mode is the kind of probe when other than a TCP connect: ModeICMP or ModeHTTP
+	Mode PingMode `json:"mode,omitempty"`
+
+	// StatusCode is the HTTP status of an HTTP check that got a response
+	StatusCode int `json:"status_code,omitempty"`
+
+	// Port is the TCP port that answered a successful probe
+	Port string `json:"port,omitempty"`
+
+	// SourcePort is the local port of a successful probe when SourcePorts is set
+	SourcePort int `json:"source_port,omitempty"`
+
+	// IPVersion is 4 or 6, the family of the address a successful probe
+	// reached; unset for proxied and dual-mode probes
+	IPVersion int `json:"ip_version,omitempty"`
+
+	// DSCP is the codepoint set on a successful probe's socket when marking was requested
+	DSCP int `json:"dscp,omitempty"`
+
+	// WarmingUp marks a failure of a newly added host within its grace
+	// period; it doesn't count towards the internet being down
+	WarmingUp bool `json:"warming_up,omitempty"`
+
+	// ProbesSent and ProbesReceived count the probes of a check when
+	// ProbesPerCheck is above one; PacketLossPercent is then the share lost
+	// (omitted when nothing was lost)
+	ProbesSent        int     `json:"probes_sent,omitempty"`
+	ProbesReceived    int     `json:"probes_received,omitempty"`
+	PacketLossPercent float64 `json:"packet_loss_percent,omitempty"`
+
+	// Attempts counts the tries made for this result when Retries is set
+	Attempts int `json:"attempts,omitempty"`
+
+	// PortResults holds every port's outcome when ProbeAllPorts is enabled
+	PortResults []PortResult `json:"ports,omitempty"`
+
+	// Checks holds each check's outcome for hosts with several HostChecks
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// defaultPorts are the TCP ports tried, in order, for each host unless
+// Monitor.Ports overrides them
+var defaultPorts = []string{"443"}
+
+// LatencyTrusted reports whether the result's latency may be used in latency aggregates
+func (r PingResult) LatencyTrusted() bool {
+	return r.Success && r.LatencyAnomaly == "" && r.Latency >= 0
+}
+
+// ResultAggregate summarises one host's results over several cycles
+type ResultAggregate struct {
+	Cycles         int   `json:"cycles"` // probed (non-paused) cycles
+	Successes      int   `json:"successes"`
+	LatencySamples int   `json:"latency_samples"` // successes with a trusted latency
+	MinLatency     int64 `json:"min_latency_ms"`
+	MaxLatency     int64 `json:"max_latency_ms"`
 }
 
 // Monitor handles network monitoring operations
@@ -21,6 +139,171 @@ type Monitor struct {
 	hosts    []string
 	interval time.Duration
 	timeout  time.Duration
+
+	// MaxPlausibleLatency is the largest latency accepted for a successful
+	// probe; anything above is flagged as an anomaly. Defaults to twice the timeout.
+	MaxPlausibleLatency time.Duration
+
+	// PauseSchedule, when set, suspends probing during its windows.
+	// Paused cycles emit Paused markers so the gap is recorded as intentional.
+	PauseSchedule *Schedule
+
+	// WarmConnections keeps one keep-alive connection per host open between
+	// HTTP probes, so their latency is a request over an established
+	// connection rather than including TCP and TLS setup. TCP connect probes
+	// have nothing to send over a held connection and always dial afresh.
+	// This changes what latency means and holds sockets open, so it is opt-in.
+	WarmConnections bool
+
+	// SkipInitialProbe disables the immediate probe when Start is called
+	SkipInitialProbe bool
+
+	// AlignToInterval schedules probes on wall-clock multiples of the
+	// interval (e.g. the top of each minute) instead of relative to startup
+	AlignToInterval bool
+
+	// Clock drives scheduling and timestamps results; defaults to the real
+	// clock. Latencies are always measured in real time.
+	Clock clock.Clock
+
+	// Network forces the address family used for probes: NetworkAuto (default),
+	// NetworkIPv4, NetworkIPv6 or NetworkDual. HostNetworks overrides it per host.
+	Network      string
+	HostNetworks map[string]string
+
+	// HostTimeouts overrides the probe timeout for individual hosts
+	HostTimeouts map[string]time.Duration
+
+	// ProbesPerCheck sends this many probes per check at once and reports
+	// their loss; the check fails when the loss reaches FailureLoss
+	// percent (default 100, i.e. only when every probe was lost). Zero or
+	// one sends a single probe.
+	ProbesPerCheck int
+	FailureLoss    float64
+
+	// Retries re-probes a failed host up to this many times before reporting
+	// the failure, waiting RetryBackoff (default DefaultRetryBackoff) before
+	// the first retry and doubling it after each. All attempts must fit in
+	// half the host's interval; retries that wouldn't are skipped.
+	Retries      int
+	RetryBackoff time.Duration
+
+	// HostIntervals overrides the probe interval for individual hosts. Hosts
+	// sharing an interval are probed together on one ticker, and each
+	// ticker's cycles are sent on the result channel as they complete.
+	HostIntervals map[string]time.Duration
+
+	// HostPorts overrides Ports for individual hosts
+	HostPorts map[string][]string
+
+	// DSCP marks probe packets with this codepoint, overridden per host by
+	// HostDSCP; zero leaves the system default
+	DSCP     int
+	HostDSCP map[string]int
+
+	// PingMode probes hosts with a TCP connect (ModeTCP), an ICMP echo
+	// (ModeICMP) or an HTTP GET of https://host/ (ModeHTTP). ICMP needs a
+	// raw socket or, on Linux, a ping_group_range including the process;
+	// without either, probes fall back to TCP. Proxied and dual-stack
+	// probes use TCP in ICMP mode. Hosts given as http(s) URLs are always
+	// HTTP checks.
+	PingMode PingMode
+
+	// HostChecks probes the listed hosts with several checks, such as a
+	// TCP connect and an HTTP GET, combined into one result (see
+	// ParseHostChecks). Hosts with a single check use PingMode.
+	HostChecks map[string]HostChecks
+
+	// ExpectBody is a substring HTTP checks require in the response body,
+	// overridden per host by HostExpectBody; empty accepts any body
+	ExpectBody     string
+	HostExpectBody map[string]string
+
+	// Plugins probes the listed hosts with an external executable instead
+	// of a TCP connect
+	Plugins map[string]*ProbeExec
+
+	// DNSServer is an optional "ip[:port]" resolver used for lookups instead
+	// of the system resolver
+	DNSServer string
+
+	// MaxConcurrency caps how many hosts are probed at once; 0 or 1 probes
+	// sequentially. See DefaultConcurrency for a limit derived from the
+	// file descriptor limit.
+	MaxConcurrency int
+
+	// Output receives console progress; defaults to os.Stdout
+	Output io.Writer
+
+	// RecordAddresses stores each host's resolved addresses on its result
+	RecordAddresses bool
+
+	// UpCriteria decides when the console reports the internet as offline;
+	// it should match the criteria used for stats
+	UpCriteria UpCriteria
+
+	// Proxy, when set, tunnels TCP probes through an HTTP CONNECT proxy
+	Proxy *url.URL
+
+	// Simulation, when set, replaces real probes with synthetic results
+	Simulation *Scenario
+
+	// Ports are the TCP ports probed, in order; defaults to defaultPorts.
+	// Probing stops at the first port that answers unless ProbeAllPorts is
+	// set, in which case every port is dialled and reported in PortResults.
+	Ports         []string
+	ProbeAllPorts bool
+
+	// SourcePorts restricts the local port of probe connections (including
+	// to the proxy) to a range; nil uses ephemeral ports
+	SourcePorts *PortRange
+
+	// Tracer, when set, records a span per cycle with a child span per probe
+	Tracer trace.Tracer
+
+	// CrashOnPanic lets panics in probes and the monitoring loop propagate.
+	// By default they are logged, counted (see Panics) and the failed probe
+	// or cycle is skipped so monitoring keeps running.
+	CrashOnPanic bool
+
+	// SlowProbeThreshold logs successful probes slower than it; zero disables.
+	// Each host logs at most once per SlowProbeInterval (default 5 minutes).
+	SlowProbeThreshold time.Duration
+	SlowProbeInterval  time.Duration
+
+	// MisconfiguredAfter flags a host that has failed to resolve this many
+	// times in a row without ever resolving (likely a typo); zero disables
+	// detection. Flagged hosts are left out of cycles and retried every
+	// MisconfiguredRetry cycles, or never when it is zero, and return to
+	// normal as soon as they resolve.
+	MisconfiguredAfter int
+	MisconfiguredRetry int
+
+	// WarmupGrace is how long failures of hosts added since the restored
+	// snapshot are marked WarmingUp and left out of up/down decisions, so
+	// a config change doesn't record a spurious outage. A host's warm-up
+	// ends early at its first success. Zero disables it.
+	WarmupGrace time.Duration
+
+	warmMu sync.Mutex
+	warm   map[string]*http.Transport
+
+	mu          sync.Mutex
+	state       map[string]HostState
+	cycles      int64
+	lastCycle   *time.Time
+	outageStart *time.Time
+	restored    bool // state was loaded from a snapshot and not yet reconciled
+
+	panics atomic.Int64
+
+	slowMu sync.Mutex
+	slow   map[string]*slowLog
+
+	resolveMu sync.Mutex
+	resolve   map[string]*resolveTrack
+
+	warmup map[string]time.Time // hosts warming up, guarded by mu
 }
 
 // NewMonitor creates a new monitor instance
@@ -29,26 +312,98 @@ func NewMonitor(hosts []string, interval, timeout time.Duration) *Monitor {
 		hosts:    hosts,
 		interval: interval,
 		timeout:  timeout,
+		PingMode: ModeTCP,
+		state:    make(map[string]HostState),
+		warm:     make(map[string]*http.Transport),
+		slow:     make(map[string]*slowLog),
+		resolve:  make(map[string]*resolveTrack),
+		warmup:   make(map[string]time.Time),
 	}
 }
 
-// Ping performs multiple connection tests to the host for reliability
+// Ping probes the host, retrying failures when Retries is set
 func (m *Monitor) Ping(host string) PingResult {
+	return m.pingWithRetries(host)
+}
+
+// pingOnce makes a single probe attempt, stamped with the clock's time when
+// it started
+func (m *Monitor) pingOnce(host string) PingResult {
+	started := m.clock().Now()
+	if m.Simulation != nil {
+		return m.Simulation.result(host, started)
+	}
+	result := m.probe(host)
+	result.Timestamp = started
+	return result
+}
+
+// probe makes a single probe attempt by the configured method
+func (m *Monitor) probe(host string) PingResult {
+	if plugin, ok := m.Plugins[host]; ok {
+		return plugin.Run(host, m.timeoutFor(host))
+	}
+	if recordType, name, ok, err := ParseDNSTarget(host); ok {
+		if err == nil {
+			name, err = ToASCII(name)
+		}
+		if err != nil {
+			return PingResult{Host: host, Timestamp: time.Now(), Error: err.Error(), ErrorCode: CodeInvalidHost}
+		}
+		return m.probeDNS(host, recordType, name)
+	}
+	if _, ok, err := ParseHTTPTarget(host); ok {
+		if err != nil {
+			return PingResult{Host: host, Timestamp: time.Now(), Error: err.Error(), ErrorCode: CodeInvalidHost}
+		}
+		return m.probeHTTP(host, host)
+	}
+	if checks, ok := m.HostChecks[host]; ok && len(checks.Checks) > 1 {
+		return m.probeChecks(host, checks)
+	}
+	return m.probeMode(host, m.PingMode)
+}
+
+// probeMode probes a host name with a TCP connect, ICMP echo or HTTP GET
+func (m *Monitor) probeMode(host string, mode PingMode) PingResult {
+	if mode == ModeHTTP {
+		target := url.URL{Scheme: "https", Host: host, Path: "/"}
+		if strings.Contains(host, ":") {
+			target.Host = "[" + host + "]" // IPv6 literal
+		}
+		return m.probeHTTP(host, target.String())
+	}
+
+	// Resolve and dial the ASCII form of internationalized names
+	name, err := ToASCII(host)
+	if err != nil {
+		return PingResult{Host: host, Timestamp: time.Now(), Error: err.Error(), ErrorCode: CodeInvalidHost}
+	}
+
 	start := time.Now()
 	result := PingResult{
 		Host:      host,
 		Timestamp: start,
 	}
+	if name != host {
+		result.ASCIIHost = name
+	}
+
+	// Through a proxy the target is resolved by the proxy, so local DNS
+	// and address-family checks don't apply
+	if m.Proxy != nil {
+		return m.connect(name, NetworkAuto, start, result)
+	}
 
 	// First, verify DNS resolution
-	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeoutFor(host))
 	defer cancel()
 
-	resolver := &net.Resolver{}
-	addrs, dnsErr := resolver.LookupHost(ctx, host)
+	addrs, dnsErr := m.resolver().LookupHost(ctx, name)
 	if dnsErr != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("DNS lookup failed: %v", dnsErr)
+		result.ErrorCode = CodeDNS
 		result.Latency = time.Since(start).Milliseconds()
 		return result
 	}
@@ -56,82 +411,304 @@ func (m *Monitor) Ping(host string) PingResult {
 	if len(addrs) == 0 {
 		result.Success = false
 		result.Error = "No IP addresses found for host"
+		result.ErrorCode = CodeNoAddress
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+	result.DNSLatency = time.Since(start).Milliseconds()
+	if m.RecordAddresses {
+		result.Addresses = slices.Sorted(slices.Values(addrs))
+	}
+
+	network := m.networkFor(host)
+	if network != NetworkAuto {
+		result.Network = network
+	}
+
+	if network == NetworkDual {
+		result.Families = m.probeFamilies(name, addrs, m.dialOptionsFor(host))
+		result.Latency = time.Since(start).Milliseconds()
+		if err := familiesError(result.Families); err != nil {
+			result.Error = err.Error()
+		}
+		for _, family := range result.Families {
+			if family.Success {
+				result.Success = true
+			}
+		}
+		if !result.Success {
+			result.ErrorCode = CodeConnect
+		}
+		if result.Success {
+			m.checkLatency(&result, time.Since(start))
+		}
+		return result
+	}
+
+	if !hasFamily(addrs, network) {
+		result.Success = false
+		result.Error = errNoFamilyAddress(network).Error()
+		result.ErrorCode = CodeNoAddress
 		result.Latency = time.Since(start).Milliseconds()
 		return result
 	}
 
-	ports := []string{"443"}
+	if mode == ModeICMP {
+		return m.echo(name, network, addrs, result)
+	}
+	return m.connect(name, network, start, result)
+}
+
+// connect dials each port of host (the ASCII name) in turn and completes
+// result with the outcome
+func (m *Monitor) connect(host, network string, start time.Time, result PingResult) PingResult {
+	if m.ProbeAllPorts {
+		return m.connectAll(host, network, start, result)
+	}
+
 	var lastErr error
+	var failedDials time.Duration
 
-	for _, port := range ports {
-		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), m.timeout)
-		latency := time.Since(start).Milliseconds()
+	opts := m.dialOptionsFor(result.Host)
+	for _, port := range opts.ports {
+		// Each attempt is timed on its own so a failed port doesn't
+		// inflate the connect latency of the next
+		dialStart := time.Now()
+		conn, err := m.dial(network, net.JoinHostPort(host, port), opts)
+		connectLatency := time.Since(dialStart)
 
 		if err == nil {
+			m.recordSourcePort(&result, conn)
+			m.recordIPVersion(&result, conn)
+			recordDSCP(&result, conn, opts.dscp)
 			conn.Close()
+			latency := successLatency(start, dialStart, failedDials, connectLatency)
 			result.Success = true
-			result.Latency = latency
+			result.Port = port
+			result.Latency = latency.Milliseconds()
+			result.ConnectLatency = connectLatency.Milliseconds()
+			m.checkLatency(&result, latency)
 			return result
 		}
+		failedDials += connectLatency
 		lastErr = err
+		// Other ports won't fare better through a broken proxy
+		if errors.As(err, new(*ProxyError)) {
+			result.ProxyError = true
+			break
+		}
 	}
 
 	// All ports failed
 	latency := time.Since(start).Milliseconds()
 	result.Success = false
 	result.Error = lastErr.Error()
+	result.ErrorCode = connectErrorCode(lastErr)
 	result.Latency = latency
 
 	return result
 }
 
+// successLatency is the latency reported for a successful dial: resolution
+// plus that dial alone, excluding time spent on ports that failed first
+func successLatency(start, dialStart time.Time, failedDials, connect time.Duration) time.Duration {
+	return dialStart.Sub(start) - failedDials + connect
+}
+
+// output returns where console progress is written
+func (m *Monitor) output() io.Writer {
+	if m.Output == nil {
+		return os.Stdout
+	}
+	return m.Output
+}
+
+// checkLatency flags a successful result whose measured latency is implausible.
+// A non-positive duration can only come from a clock anomaly, and a success
+// slower than the plausible maximum means the measurement can't be trusted.
+func (m *Monitor) checkLatency(result *PingResult, elapsed time.Duration) {
+	maxLatency := m.MaxPlausibleLatency
+	if maxLatency <= 0 {
+		maxLatency = 2 * m.timeoutFor(result.Host)
+	}
+
+	switch {
+	case elapsed <= 0:
+		result.LatencyAnomaly = fmt.Sprintf("non-positive latency (%v)", elapsed)
+	case elapsed > maxLatency:
+		result.LatencyAnomaly = fmt.Sprintf("latency %v exceeds plausible maximum %v", elapsed, maxLatency)
+	}
+}
+
 // PingAll pings all configured hosts and reports overall connectivity
 func (m *Monitor) PingAll() []PingResult {
-	results := make([]PingResult, 0, len(m.hosts))
-	successCount := 0
+	return m.pingHosts(m.hosts)
+}
 
-	for _, host := range m.hosts {
-		result := m.Ping(host)
-		results = append(results, result)
+// pingHosts runs one cycle over hosts and reports overall connectivity.
+// Console lines are written at once so concurrent cycles don't interleave.
+func (m *Monitor) pingHosts(hosts []string) []PingResult {
+	ctx, span := m.startCycleSpan(len(hosts))
+	results := m.probeHosts(ctx, hosts)
+	m.markWarmingUp(results)
+
+	var out strings.Builder
+	for _, result := range results {
 
 		status := "✗ FAIL"
 		if result.Success {
 			status = "✓ OK"
-			successCount++
 		}
 
-		fmt.Printf("  %s %-20s %s (latency: %dms)\n",
+		note := ""
+		if result.LatencyAnomaly != "" {
+			note = "suspect: " + result.LatencyAnomaly
+		}
+		if result.WarmingUp {
+			note = "warming up, not counted"
+		}
+		if result.Simulated {
+			note = strings.TrimSpace("[simulated] " + note)
+		}
+
+		fmt.Fprintf(&out, "  %s %-20s %s (latency: %dms)\n",
 			status,
 			result.Host,
-			"",
+			note,
 			result.Latency)
 	}
 
-	// Overall connectivity status
+	// Overall connectivity status, on the latest result of every host
+	latest := m.record(results)
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	if successCount == 0 {
-		fmt.Printf("\n[%s] ⚠️  INTERNET: OFFLINE - All hosts unreachable\n\n", timestamp)
+	online, failedHosts := IsInternetUp(latest, m.UpCriteria)
+	if !online {
+		if len(failedHosts) == len(latest) {
+			fmt.Fprintf(&out, "\n[%s] ⚠️  INTERNET: OFFLINE - All hosts unreachable\n\n", timestamp)
+		} else {
+			fmt.Fprintf(&out, "\n[%s] ⚠️  INTERNET: OFFLINE - %d of %d hosts unreachable\n\n", timestamp, len(failedHosts), len(latest))
+		}
 	}
+	io.WriteString(m.output(), out.String())
+	m.logSlowProbes(results)
+	m.trackResolution(results)
+	endCycleSpan(span, online, len(failedHosts))
 
 	return results
 }
 
-// Start begins continuous monitoring
+// pausedResults returns a Paused marker for each of hosts
+func (m *Monitor) pausedResults(hosts []string) []PingResult {
+	now := m.clock().Now()
+	results := make([]PingResult, 0, len(hosts))
+	for _, host := range hosts {
+		results = append(results, PingResult{
+			Host:      host,
+			Paused:    true,
+			Timestamp: now,
+		})
+	}
+	return results
+}
+
+// clock returns the configured clock or the real one
+func (m *Monitor) clock() clock.Clock {
+	if m.Clock == nil {
+		return clock.RealClock{}
+	}
+	return m.Clock
+}
+
+// runCycle probes hosts, or emits paused markers while the pause schedule is active
+func (m *Monitor) runCycle(hosts []string, wasPaused bool) (results []PingResult, paused bool) {
+	if m.PauseSchedule != nil && m.PauseSchedule.Active(m.clock().Now()) {
+		if !wasPaused {
+			fmt.Fprintf(m.output(), "[%s] Monitoring paused by schedule\n", m.clock().Now().Format("2006-01-02 15:04:05"))
+		}
+		return m.pausedResults(hosts), true
+	}
+	if wasPaused {
+		fmt.Fprintf(m.output(), "[%s] Monitoring resumed\n", m.clock().Now().Format("2006-01-02 15:04:05"))
+	}
+	return m.pingHosts(hosts), false
+}
+
+// groupState is what a group's loop carries from one cycle to the next
+type groupState struct {
+	paused      bool
+	overrunning bool // the last cycle took longer than the interval
+}
+
+// sendCycle runs one cycle over a group and sends its results, if any, on
+// resultChan. Cycles outlasting the interval are reported when they start
+// and stop doing so, as the ticker drops the ticks they miss.
+func (m *Monitor) sendCycle(group hostGroup, resultChan chan<- []PingResult, state *groupState) {
+	var results []PingResult
+	start := time.Now()
+	results, state.paused = m.safeCycle(group.hosts, state.paused)
+
+	elapsed := time.Since(start)
+	overrun := elapsed > group.interval
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	switch {
+	case overrun && !state.overrunning:
+		fmt.Fprintf(m.output(), "[%s] Warning: a cycle took %v, longer than the %v interval; checks are being skipped (allow more concurrent probes or shorten timeouts)\n",
+			timestamp, elapsed.Round(time.Millisecond), group.interval)
+	case !overrun && state.overrunning:
+		fmt.Fprintf(m.output(), "[%s] Cycles are back within the %v interval\n", timestamp, group.interval)
+	}
+	state.overrunning = overrun
+
+	if results != nil {
+		resultChan <- results
+	}
+}
+
+// Start begins continuous monitoring. Each host runs on its own ticker at
+// its interval; all send on resultChan.
 func (m *Monitor) Start(resultChan chan<- []PingResult, stopChan <-chan struct{}) {
-	ticker := time.NewTicker(m.interval)
-	defer ticker.Stop()
+	defer m.closeWarm()
+
+	var wg sync.WaitGroup
+	for _, group := range m.hostGroups() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.run(group, resultChan, stopChan)
+		}()
+	}
+	wg.Wait()
+	fmt.Fprintln(m.output(), "Monitor stopped")
+}
 
-	// Perform initial ping immediately
-	results := m.PingAll()
-	resultChan <- results
+// run probes a group's hosts every group interval until stopChan is closed
+func (m *Monitor) run(group hostGroup, resultChan chan<- []PingResult, stopChan <-chan struct{}) {
+	clk := m.clock()
+	var state groupState
+
+	// Perform initial ping immediately unless disabled
+	if !m.SkipInitialProbe {
+		m.sendCycle(group, resultChan, &state)
+	}
+
+	// Wait for the next wall-clock boundary so subsequent ticks line up with it
+	if m.AlignToInterval {
+		select {
+		case <-clk.After(clock.UntilBoundary(clk.Now(), group.interval)):
+			m.sendCycle(group, resultChan, &state)
+		case <-stopChan:
+			return
+		}
+	}
+
+	ticker := clk.NewTicker(group.interval)
+	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			results := m.PingAll()
-			resultChan <- results
+		case <-ticker.C():
+			m.sendCycle(group, resultChan, &state)
 		case <-stopChan:
-			fmt.Println("Monitor stopped")
 			return
 		}
 	}
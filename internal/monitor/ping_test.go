@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckLatency(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       time.Duration
+		elapsed   time.Duration
+		anomalous bool
+	}{
+		{"zero", time.Second, 0, true},
+		{"negative", time.Second, -5 * time.Millisecond, true},
+		{"smallest positive", time.Second, time.Nanosecond, false},
+		{"typical", time.Second, 40 * time.Millisecond, false},
+		{"at the maximum", time.Second, time.Second, false},
+		{"above the maximum", time.Second, time.Second + time.Nanosecond, true},
+		{"default maximum is twice the timeout", 0, 3 * time.Second, false},
+		{"above the default maximum", 0, 4*time.Second + time.Millisecond, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMonitor([]string{"example.com"}, time.Minute, 2*time.Second)
+			m.MaxPlausibleLatency = test.max
+			result := PingResult{Host: "example.com", Success: true}
+
+			m.checkLatency(&result, test.elapsed)
+			if got := result.LatencyAnomaly != ""; got != test.anomalous {
+				t.Errorf("anomaly = %q, want anomalous %v", result.LatencyAnomaly, test.anomalous)
+			}
+		})
+	}
+}
+
+func TestSuccessLatencyExcludesFailedPorts(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	const dns = 20 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		failedDials time.Duration
+		connect     time.Duration
+		want        time.Duration
+	}{
+		{"first port answers", 0, 30 * time.Millisecond, 50 * time.Millisecond},
+		{"one slow failure first", 400 * time.Millisecond, 30 * time.Millisecond, 50 * time.Millisecond},
+		{"several failures first", 2 * time.Second, 5 * time.Millisecond, 25 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dialStart := start.Add(dns + test.failedDials)
+			if got := successLatency(start, dialStart, test.failedDials, test.connect); got != test.want {
+				t.Errorf("latency = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckLatencyUsesHostTimeout(t *testing.T) {
+	m := NewMonitor([]string{"slow.example"}, time.Minute, time.Second)
+	m.HostTimeouts = map[string]time.Duration{"slow.example": 10 * time.Second}
+	result := PingResult{Host: "slow.example", Success: true}
+
+	m.checkLatency(&result, 5*time.Second)
+	if result.LatencyAnomaly != "" {
+		t.Errorf("5s flagged against a 10s host timeout: %q", result.LatencyAnomaly)
+	}
+}
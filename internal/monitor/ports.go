@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PortResult reports the outcome for one port in all-ports mode
+type PortResult struct {
+	Port    string `json:"port"`
+	Success bool   `json:"success"`
+	Latency int64  `json:"latency_ms"` // connect time alone, excluding DNS
+	Error   string `json:"error,omitempty"`
+}
+
+// ParsePorts parses a comma-separated list of TCP ports such as "443,80"
+func ParsePorts(spec string) ([]string, error) {
+	var ports []string
+	for _, port := range strings.Split(spec, ",") {
+		port = strings.TrimSpace(port)
+		if port == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 0 {
+		return nil, errors.New("no ports given")
+	}
+	return ports, nil
+}
+
+// WithPorts sets the TCP ports probed, in order, and returns m, so it can
+// follow NewMonitor: NewMonitor(hosts, interval, timeout).WithPorts("22", "5432").
+// Calling it with no ports restores the default.
+func (m *Monitor) WithPorts(ports ...string) *Monitor {
+	m.Ports = ports
+	return m
+}
+
+// portsFor returns the probe ports configured for a host or the defaults
+func (m *Monitor) portsFor(host string) []string {
+	if ports, ok := m.HostPorts[host]; ok && len(ports) > 0 {
+		return ports
+	}
+	if len(m.Ports) == 0 {
+		return defaultPorts
+	}
+	return m.Ports
+}
+
+// connectAll dials every port and records each outcome. The host is up if
+// any port answered; its latency is resolution plus the fastest connect.
+func (m *Monitor) connectAll(host, network string, start time.Time, result PingResult) PingResult {
+	dnsTime := time.Since(start)
+	var fastest time.Duration
+	var errs []string
+	var lastErr error
+
+	opts := m.dialOptionsFor(result.Host)
+	for _, port := range opts.ports {
+		dialStart := time.Now()
+		conn, err := m.dial(network, net.JoinHostPort(host, port), opts)
+		connectLatency := time.Since(dialStart)
+
+		portResult := PortResult{Port: port, Latency: connectLatency.Milliseconds()}
+		if err != nil {
+			portResult.Error = err.Error()
+			errs = append(errs, port+": "+err.Error())
+			lastErr = err
+			if errors.As(err, new(*ProxyError)) {
+				result.ProxyError = true
+			}
+		} else {
+			portResult.Success = true
+			recordDSCP(&result, conn, opts.dscp) // read before the connection is closed
+			conn.Close()
+			if !result.Success || connectLatency < fastest {
+				fastest = connectLatency
+				result.Port = port
+				m.recordSourcePort(&result, conn)
+				m.recordIPVersion(&result, conn)
+			}
+			result.Success = true
+		}
+		result.PortResults = append(result.PortResults, portResult)
+	}
+
+	if !result.Success {
+		result.Error = strings.Join(errs, "; ")
+		result.ErrorCode = connectErrorCode(lastErr)
+		result.Latency = time.Since(start).Milliseconds()
+		return result
+	}
+
+	latency := dnsTime + fastest
+	result.ProxyError = false
+	result.Latency = latency.Milliseconds()
+	result.ConnectLatency = fastest.Milliseconds()
+	m.checkLatency(&result, latency)
+	return result
+}
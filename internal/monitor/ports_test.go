@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+const slowPortDelay = 400 * time.Millisecond
+
+// slowPortProxy starts an HTTP CONNECT proxy that stalls for slowPortDelay
+// before reporting port 1 unreachable, and tunnels port 2 at once
+func slowPortProxy(t *testing.T) *url.URL {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				if _, port, _ := net.SplitHostPort(req.Host); port == "1" {
+					time.Sleep(slowPortDelay)
+					io.WriteString(conn, "HTTP/1.1 504 Gateway Timeout\r\n\r\n")
+					return
+				}
+				io.WriteString(conn, "HTTP/1.1 200 OK\r\n\r\n")
+			}()
+		}
+	}()
+	return &url.URL{Scheme: "http", Host: listener.Addr().String()}
+}
+
+func slowPortMonitor(t *testing.T) *Monitor {
+	m := NewMonitor([]string{"target.example"}, time.Minute, 2*time.Second)
+	m.Output = io.Discard
+	m.Proxy = slowPortProxy(t)
+	m.Ports = []string{"1", "2"}
+	return m
+}
+
+func TestConnectExcludesFailedPortFromLatency(t *testing.T) {
+	result := slowPortMonitor(t).Ping("target.example")
+
+	if !result.Success || result.Port != "2" {
+		t.Fatalf("success=%v port=%q (%s), want success on port 2", result.Success, result.Port, result.Error)
+	}
+	if limit := slowPortDelay.Milliseconds() / 2; result.Latency >= limit {
+		t.Errorf("latency %dms includes the failed port's %v", result.Latency, slowPortDelay)
+	}
+}
+
+func TestConnectAllReportsFastestPortLatency(t *testing.T) {
+	m := slowPortMonitor(t)
+	m.ProbeAllPorts = true
+	result := m.Ping("target.example")
+
+	if !result.Success || result.Port != "2" {
+		t.Fatalf("success=%v port=%q (%s), want success on port 2", result.Success, result.Port, result.Error)
+	}
+	if len(result.PortResults) != 2 {
+		t.Fatalf("got %d port results, want 2", len(result.PortResults))
+	}
+	if slow := result.PortResults[0]; slow.Success || slow.Latency < slowPortDelay.Milliseconds() {
+		t.Errorf("port 1: success=%v latency=%dms, want a failure after %v", slow.Success, slow.Latency, slowPortDelay)
+	}
+	if limit := slowPortDelay.Milliseconds() / 2; result.Latency >= limit {
+		t.Errorf("latency %dms includes the failed port's %v", result.Latency, slowPortDelay)
+	}
+}
+
+func TestWithPorts(t *testing.T) {
+	m := NewMonitor([]string{"db.internal"}, time.Minute, time.Second).WithPorts("22", "5432")
+	if got := m.portsFor("db.internal"); !slices.Equal(got, []string{"22", "5432"}) {
+		t.Errorf("ports = %v, want [22 5432]", got)
+	}
+	if got := m.WithPorts().portsFor("db.internal"); !slices.Equal(got, defaultPorts) {
+		t.Errorf("ports after WithPorts() = %v, want the default %v", got, defaultPorts)
+	}
+}
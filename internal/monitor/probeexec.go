@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Plugin output bounds; anything beyond is discarded
+const (
+	maxPluginOutput = 1 << 20
+	maxPluginStderr = 4 << 10
+)
+
+// ProbeExec is an external probe: an executable run directly (never through
+// a shell) with a ProbeRequest on stdin, which must print a PingResult as
+// JSON on stdout and exit zero before the probe timeout
+type ProbeExec struct {
+	Path string
+	Args []string
+}
+
+// ProbeRequest is the JSON document a probe plugin receives on stdin
+type ProbeRequest struct {
+	Host      string `json:"host"`
+	TimeoutMs int64  `json:"timeout_ms"`
+}
+
+// ParseProbeExec parses "path [args...]" into a probe plugin, resolving the
+// path like a shell would but without invoking one
+func ParseProbeExec(spec string) (*ProbeExec, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, errors.New("empty plugin command")
+	}
+	path, err := exec.LookPath(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ProbeExec{Path: path, Args: fields[1:]}, nil
+}
+
+// String returns the plugin's command line
+func (p *ProbeExec) String() string {
+	return strings.Join(append([]string{p.Path}, p.Args...), " ")
+}
+
+// Run probes host with the plugin. The plugin gets a minimal environment
+// and is killed at the timeout; a non-zero exit, a timeout or output that
+// isn't a PingResult is reported as a failure with CodePlugin.
+func (p *ProbeExec) Run(host string, timeout time.Duration) PingResult {
+	start := time.Now()
+	fail := func(format string, args ...any) PingResult {
+		return PingResult{
+			Host:      host,
+			Timestamp: start,
+			Latency:   time.Since(start).Milliseconds(),
+			Error:     fmt.Sprintf(format, args...),
+			ErrorCode: CodePlugin,
+		}
+	}
+
+	request, err := json.Marshal(ProbeRequest{Host: host, TimeoutMs: timeout.Milliseconds()})
+	if err != nil {
+		return fail("probe plugin request: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxPluginOutput, maxPluginStderr
+	cmd := exec.CommandContext(ctx, p.Path, p.Args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	cmd.WaitDelay = time.Second // don't wait on children still holding the pipes
+
+	err = cmd.Run()
+	latency := time.Since(start)
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return fail("probe plugin timed out after %v", timeout)
+	case err != nil:
+		if detail := firstLine(stderr.String()); detail != "" {
+			return fail("probe plugin failed: %v: %s", err, detail)
+		}
+		return fail("probe plugin failed: %v", err)
+	}
+
+	var result PingResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return fail("probe plugin returned malformed output: %v", err)
+	}
+
+	// The plugin reports the outcome; identity and timing are ours
+	result.Host = host
+	result.Timestamp = start
+	if result.Latency <= 0 {
+		result.Latency = latency.Milliseconds()
+	}
+	if !result.Success && result.Error == "" {
+		result.Error = "probe plugin reported failure"
+	}
+	return result
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// firstLine returns the first non-empty line of s, trimmed
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// Panics returns how many panics have been recovered so far
+func (m *Monitor) Panics() int64 {
+	return m.panics.Load()
+}
+
+// recovered logs a recovered panic with its stack and counts it
+func (m *Monitor) recovered(where string, value any) {
+	m.panics.Add(1)
+	fmt.Fprintf(os.Stderr, "[%s] PANIC in %s (recovered): %v\n%s\n",
+		time.Now().Format("2006-01-02 15:04:05"), where, value, debug.Stack())
+}
+
+// safePing runs Ping, turning a panic into a failed result for that host
+// so one broken probe doesn't take monitoring down
+func (m *Monitor) safePing(host string) (result PingResult) {
+	if m.CrashOnPanic {
+		return m.Ping(host)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.recovered("probe of "+host, r)
+			result = PingResult{
+				Host:      host,
+				Timestamp: m.clock().Now(),
+				Error:     fmt.Sprintf("probe panicked: %v", r),
+				ErrorCode: CodePanic,
+			}
+		}
+	}()
+	return m.Ping(host)
+}
+
+// safeCycle runs a cycle, recovering from a panic outside the probes
+// themselves (e.g. while reporting). A failed cycle produces no results
+// and monitoring carries on with the next tick.
+func (m *Monitor) safeCycle(hosts []string, wasPaused bool) (results []PingResult, paused bool) {
+	if m.CrashOnPanic {
+		return m.runCycle(hosts, wasPaused)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.recovered("monitoring cycle", r)
+			results, paused = nil, wasPaused
+		}
+	}()
+	return m.runCycle(hosts, wasPaused)
+}
@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPanickingProbeFailsOnlyItsHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// A nil plugin panics on a nil pointer dereference when run
+	m := NewMonitor([]string{"broken.example", server.URL}, time.Minute, time.Second)
+	m.Output = io.Discard
+	m.Plugins = map[string]*ProbeExec{"broken.example": nil}
+
+	for cycle := 1; cycle <= 2; cycle++ {
+		results := m.PingAll()
+		if len(results) != 2 {
+			t.Fatalf("cycle %d: got %d results, want 2", cycle, len(results))
+		}
+		if results[0].Success || results[0].Code() != CodePanic {
+			t.Errorf("cycle %d: broken host success=%v code=%q, want a panic failure", cycle, results[0].Success, results[0].Code())
+		}
+		if !results[1].Success {
+			t.Errorf("cycle %d: healthy host failed: %s", cycle, results[1].Error)
+		}
+		if got := m.Panics(); got != int64(cycle) {
+			t.Errorf("cycle %d: Panics() = %d, want %d", cycle, got, cycle)
+		}
+	}
+}
+
+func TestCrashOnPanicPropagates(t *testing.T) {
+	m := NewMonitor([]string{"broken.example"}, time.Minute, time.Second)
+	m.Output = io.Discard
+	m.Plugins = map[string]*ProbeExec{"broken.example": nil}
+	m.CrashOnPanic = true
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("probe panic was recovered with CrashOnPanic set")
+		}
+	}()
+	m.PingAll()
+}
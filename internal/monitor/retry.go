@@ -0,0 +1,54 @@
+package monitor
+
+import "time"
+
+// DefaultRetryBackoff is the wait before the first retry when RetryBackoff is unset
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// retryable reports whether a failed result may succeed on another attempt.
+// Invalid hosts fail the same way every time and simulated results follow
+// their scenario, so neither is retried.
+func retryable(result PingResult) bool {
+	switch result.Code() {
+	case "", CodeInvalidHost, CodeSimulated:
+		return false
+	}
+	return true
+}
+
+// retryDeadline bounds the time a host's attempts may take, so a flapping
+// host can't hold up its cycle: half the host's interval
+func (m *Monitor) retryDeadline(host string) time.Duration {
+	return m.intervalFor(host) / 2
+}
+
+// pingWithRetries probes host, retrying failures up to Retries times with
+// exponential backoff. A retry only starts if it can time out before the
+// retry deadline. The result of the last attempt is returned, with
+// Attempts counting every try.
+func (m *Monitor) pingWithRetries(host string) PingResult {
+	start := time.Now()
+	result := m.check(host)
+	if m.Retries <= 0 {
+		return result
+	}
+
+	backoff := m.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	deadline := start.Add(m.retryDeadline(host))
+
+	attempts := 1
+	for attempts <= m.Retries && !result.Success && retryable(result) {
+		if time.Now().Add(backoff + m.timeoutFor(host)).After(deadline) {
+			break
+		}
+		time.Sleep(backoff)
+		result = m.check(host)
+		attempts++
+		backoff *= 2
+	}
+	result.Attempts = attempts
+	return result
+}
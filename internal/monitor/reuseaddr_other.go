@@ -0,0 +1,10 @@
+//go:build !unix
+
+package monitor
+
+import "syscall"
+
+// reuseAddr is a no-op where SO_REUSEADDR has different semantics
+func reuseAddr(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is a set of recurring weekly windows during which probing is paused
+type Schedule struct {
+	windows  []window
+	location *time.Location
+}
+
+// window is a daily time range applied to a set of weekdays.
+// When end is before start the window wraps past midnight.
+type window struct {
+	days  [7]bool
+	start time.Duration // offset from midnight
+	end   time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseSchedule parses semicolon-separated windows such as
+// "Mon-Fri 01:00-05:00; Sat,Sun 02:00-03:00; 23:30-00:15".
+// Windows without days apply every day. Times are interpreted in loc.
+func ParseSchedule(spec string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	schedule := &Schedule{location: loc}
+
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		var w window
+		var timeRange string
+
+		switch len(fields) {
+		case 1:
+			for i := range w.days {
+				w.days[i] = true
+			}
+			timeRange = fields[0]
+		case 2:
+			days, err := parseDays(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("window %q: %w", part, err)
+			}
+			w.days = days
+			timeRange = fields[1]
+		default:
+			return nil, fmt.Errorf("window %q: expected \"[days] HH:MM-HH:MM\"", part)
+		}
+
+		startStr, endStr, ok := strings.Cut(timeRange, "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q: expected time range HH:MM-HH:MM", part)
+		}
+		var err error
+		if w.start, err = parseClock(startStr); err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		if w.end, err = parseClock(endStr); err != nil {
+			return nil, fmt.Errorf("window %q: %w", part, err)
+		}
+		if w.start == w.end {
+			return nil, fmt.Errorf("window %q: start and end are equal", part)
+		}
+
+		schedule.windows = append(schedule.windows, w)
+	}
+
+	if len(schedule.windows) == 0 {
+		return nil, fmt.Errorf("schedule %q contains no windows", spec)
+	}
+	return schedule, nil
+}
+
+// parseDays parses day lists like "Mon-Fri" or "Sat,Sun"
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+	for _, item := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := weekdays[strings.ToLower(from)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[strings.ToLower(to)]; !ok {
+				return days, fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses a HH:MM time of day into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Active reports whether t falls inside one of the schedule's windows
+func (s *Schedule) Active(t time.Time) bool {
+	t = t.In(s.location)
+	day := t.Weekday()
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if w.days[day] && offset >= w.start && offset < w.end {
+				return true
+			}
+			continue
+		}
+		// Wrapping window: the late part belongs to today, the early part to yesterday's window
+		if w.days[day] && offset >= w.start {
+			return true
+		}
+		if w.days[(day+6)%7] && offset < w.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"monitrix/internal/clock"
+)
+
+func TestScheduleActiveAsTimeAdvances(t *testing.T) {
+	schedule, err := ParseSchedule("Mon-Fri 01:00-05:00; Sat 23:30-00:15", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Monday 2024-03-04, just before the weekday window
+	now := time.Date(2024, 3, 4, 0, 59, 0, 0, time.UTC)
+
+	steps := []struct {
+		advance time.Duration
+		want    bool
+		when    string
+	}{
+		{0, false, "Mon 00:59"},
+		{time.Minute, true, "Mon 01:00, window opens"},
+		{4*time.Hour - time.Second, true, "Mon 04:59:59"},
+		{time.Second, false, "Mon 05:00, window closes"},
+		{4*24*time.Hour - 4*time.Hour, true, "Fri 01:00"},
+		{24 * time.Hour, false, "Sat 01:00, weekdays only"},
+		{22*time.Hour + 30*time.Minute, true, "Sat 23:30, wrapping window opens"},
+		{40 * time.Minute, true, "Sun 00:10, still in Saturday's window"},
+		{5 * time.Minute, false, "Sun 00:15, wrapping window closes"},
+		{24 * time.Hour, false, "Mon 00:15, not Sunday's window"},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if got := schedule.Active(now); got != step.want {
+			t.Errorf("%s (%v): Active = %v, want %v", step.when, now, got, step.want)
+		}
+	}
+}
+
+func TestScheduleUsesItsLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	schedule, err := ParseSchedule("02:00-03:00", tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 17:30 UTC is 02:30 in Tokyo
+	now := time.Date(2024, 3, 4, 17, 30, 0, 0, time.UTC)
+	if !schedule.Active(now) {
+		t.Error("02:30 local time isn't in the 02:00-03:00 window")
+	}
+	if schedule.Active(now.Add(time.Hour)) {
+		t.Error("03:30 local time is in the 02:00-03:00 window")
+	}
+}
+
+func TestParseScheduleRejectsInvalidWindows(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		" ; ",
+		"01:00",
+		"25:00-02:00",
+		"01:00-01:00",
+		"Funday 01:00-02:00",
+		"Mon-Fri 01:00-02:00 extra",
+	} {
+		if _, err := ParseSchedule(spec, time.UTC); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestRunCyclePausesOnClockSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("01:00-02:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := clock.NewFakeClock(time.Date(2024, 3, 4, 1, 30, 0, 0, time.UTC))
+	var out bytes.Buffer
+	m := NewMonitor([]string{"example.com"}, time.Minute, time.Second)
+	m.Clock = fake
+	m.Output = &out
+	m.PauseSchedule = schedule
+	if m.Simulation, err = ParseScenario("up=100,seed=1"); err != nil {
+		t.Fatal(err)
+	}
+
+	results, paused := m.runCycle(m.hosts, false)
+	if !paused || len(results) != 1 || !results[0].Paused || !results[0].Timestamp.Equal(fake.Now()) {
+		t.Fatalf("inside the window: paused=%v results=%+v, want a paused marker at %v", paused, results, fake.Now())
+	}
+	if !strings.Contains(out.String(), "[2024-03-04 01:30:00] Monitoring paused by schedule") {
+		t.Errorf("pause not logged at the clock's time: %q", out.String())
+	}
+
+	fake.Advance(time.Hour)
+	results, paused = m.runCycle(m.hosts, paused)
+	if paused || len(results) != 1 || results[0].Paused {
+		t.Fatalf("after the window: paused=%v results=%+v, want a probe", paused, results)
+	}
+	if !strings.Contains(out.String(), "[2024-03-04 02:30:00] Monitoring resumed") {
+		t.Errorf("resume not logged at the clock's time: %q", out.String())
+	}
+}
@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scenario describes synthetic probe results produced instead of real
+// probes, for developing and demoing without a network
+type Scenario struct {
+	SuccessRate  float64       // probability (0–1) that a probe outside an outage succeeds
+	OutageEvery  time.Duration // period between injected outages; zero disables them
+	OutageLength time.Duration // how long each injected outage lasts
+	MinLatency   time.Duration
+	MaxLatency   time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// DefaultScenario is used when SIMULATE is enabled without a scenario
+const DefaultScenario = "up=95,outage=5m/1h,latency=20-80,seed=1"
+
+// ParseScenario parses comma-separated settings such as
+// "up=95,outage=5m/1h,latency=20-80,seed=1". up is a percentage, outage is
+// length/period, latency is a millisecond range, and seed makes runs repeatable.
+func ParseScenario(spec string) (*Scenario, error) {
+	scenario := &Scenario{
+		SuccessRate: 1,
+		MinLatency:  20 * time.Millisecond,
+		MaxLatency:  80 * time.Millisecond,
+	}
+	var seed uint64
+
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", field)
+		}
+
+		switch key {
+		case "up":
+			pct, err := strconv.ParseFloat(value, 64)
+			if err != nil || pct < 0 || pct > 100 {
+				return nil, fmt.Errorf("up must be a percentage, got %q", value)
+			}
+			scenario.SuccessRate = pct / 100
+		case "outage":
+			lengthStr, everyStr, ok := strings.Cut(value, "/")
+			if !ok {
+				return nil, fmt.Errorf("outage must be length/period, got %q", value)
+			}
+			length, err := time.ParseDuration(lengthStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid outage length %q: %w", lengthStr, err)
+			}
+			every, err := time.ParseDuration(everyStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid outage period %q: %w", everyStr, err)
+			}
+			if length <= 0 || every <= length {
+				return nil, fmt.Errorf("outage length must be positive and shorter than its period")
+			}
+			scenario.OutageLength, scenario.OutageEvery = length, every
+		case "latency":
+			minStr, maxStr, _ := strings.Cut(value, "-")
+			if maxStr == "" {
+				maxStr = minStr
+			}
+			minMs, err1 := strconv.Atoi(minStr)
+			maxMs, err2 := strconv.Atoi(maxStr)
+			if err1 != nil || err2 != nil || minMs < 0 || maxMs < minMs {
+				return nil, fmt.Errorf("latency must be a millisecond range such as 20-80, got %q", value)
+			}
+			scenario.MinLatency = time.Duration(minMs) * time.Millisecond
+			scenario.MaxLatency = time.Duration(maxMs) * time.Millisecond
+		case "seed":
+			s, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid seed %q", value)
+			}
+			seed = s
+		default:
+			return nil, fmt.Errorf("unknown scenario setting %q", key)
+		}
+	}
+
+	scenario.rng = rand.New(rand.NewPCG(seed, seed))
+	return scenario, nil
+}
+
+// InOutage reports whether t falls inside an injected outage. Outages start
+// at each multiple of OutageEvery since the Unix epoch.
+func (s *Scenario) InOutage(t time.Time) bool {
+	if s.OutageEvery <= 0 {
+		return false
+	}
+	return time.Duration(t.UnixNano()%int64(s.OutageEvery)) < s.OutageLength
+}
+
+// result fabricates a probe result for host at t
+func (s *Scenario) result(host string, t time.Time) PingResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := PingResult{
+		Host:      host,
+		Timestamp: t,
+		Simulated: true,
+	}
+
+	switch {
+	case s.InOutage(t):
+		result.Error = "simulated outage"
+		result.ErrorCode = CodeSimulated
+	case s.rng.Float64() >= s.SuccessRate:
+		result.Error = "simulated probe failure"
+		result.ErrorCode = CodeSimulated
+	default:
+		result.Success = true
+		spread := int64(s.MaxLatency - s.MinLatency)
+		latency := s.MinLatency
+		if spread > 0 {
+			latency += time.Duration(s.rng.Int64N(spread + 1))
+		}
+		result.Latency = latency.Milliseconds()
+	}
+	return result
+}
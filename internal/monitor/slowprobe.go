@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultSlowProbeInterval is how often a persistently slow host is logged
+const defaultSlowProbeInterval = 5 * time.Minute
+
+// slowLog rate-limits slow-probe lines for one host
+type slowLog struct {
+	last       time.Time
+	suppressed int
+}
+
+// logSlowProbes writes a line for each successful probe slower than the
+// threshold, at most once per interval per host. Lines suppressed in the
+// meantime are counted on the next one.
+func (m *Monitor) logSlowProbes(results []PingResult) {
+	if m.SlowProbeThreshold <= 0 {
+		return
+	}
+	interval := m.SlowProbeInterval
+	if interval <= 0 {
+		interval = defaultSlowProbeInterval
+	}
+
+	m.slowMu.Lock()
+	defer m.slowMu.Unlock()
+
+	now := time.Now()
+	for _, result := range results {
+		latency := time.Duration(result.Latency) * time.Millisecond
+		if !result.LatencyTrusted() || latency <= m.SlowProbeThreshold {
+			continue
+		}
+
+		entry, ok := m.slow[result.Host]
+		if !ok {
+			entry = &slowLog{}
+			m.slow[result.Host] = entry
+		}
+		if !entry.last.IsZero() && now.Sub(entry.last) < interval {
+			entry.suppressed++
+			continue
+		}
+
+		suppressed := ""
+		if entry.suppressed > 0 {
+			suppressed = fmt.Sprintf(", %d more since last report", entry.suppressed)
+		}
+		fmt.Fprintf(m.output(), "[%s] SLOW PROBE %s: %dms via %s (threshold %dms%s)\n",
+			now.Format("2006-01-02 15:04:05"),
+			result.Host,
+			result.Latency,
+			m.probeMethod(result),
+			m.SlowProbeThreshold.Milliseconds(),
+			suppressed)
+		entry.last = now
+		entry.suppressed = 0
+	}
+}
+
+// probeMethod describes how a result was obtained, e.g. "tcp4 port 443 via proxy"
+func (m *Monitor) probeMethod(result PingResult) string {
+	if recordType, _, ok, _ := ParseDNSTarget(result.Host); ok {
+		return "dns " + recordType
+	}
+	if result.Simulated {
+		return "simulation"
+	}
+
+	method := m.networkFor(result.Host)
+	if result.Port != "" {
+		method += " port " + result.Port
+	}
+	if m.Proxy != nil {
+		method += " via proxy"
+	}
+	return method
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SaveSnapshot writes the current state to path, replacing it atomically
+func (m *Monitor) SaveSnapshot(path string) error {
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads a state snapshot written by SaveSnapshot
+func LoadSnapshot(path string) (State, error) {
+	var state State
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return state, nil
+}
+
+// Restore seeds the live state from a snapshot so it can be served before
+// the first cycle completes. The snapshot is only a cache: the first cycle
+// after Restore replaces it.
+func (m *Monitor) Restore(state State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cycles > 0 {
+		return
+	}
+
+	m.state = make(map[string]HostState, len(state.Hosts))
+	for host, hostState := range state.Hosts {
+		m.state[host] = hostState
+	}
+	m.lastCycle = state.LastCycle
+	m.outageStart = state.OutageStart
+	m.restored = true
+	m.startWarmup(state.Hosts)
+}
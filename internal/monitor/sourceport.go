@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PortRange is an inclusive range of local source ports
+type PortRange struct {
+	Low  int
+	High int
+}
+
+// ParsePortRange parses a single port ("40000") or a range ("40000-40100")
+func ParsePortRange(spec string) (*PortRange, error) {
+	lowStr, highStr, isRange := strings.Cut(strings.TrimSpace(spec), "-")
+	if !isRange {
+		highStr = lowStr
+	}
+	low, errLow := strconv.Atoi(strings.TrimSpace(lowStr))
+	high, errHigh := strconv.Atoi(strings.TrimSpace(highStr))
+	if errLow != nil || errHigh != nil || low < 1 || high > 65535 || low > high {
+		return nil, fmt.Errorf("invalid port range %q, expected e.g. 40000-40100", spec)
+	}
+	return &PortRange{Low: low, High: high}, nil
+}
+
+// dialFrom dials address from a free source port in ports. It starts at a
+// random port and moves on while ports are taken, giving up once the range
+// is exhausted.
+func dialFrom(ports *PortRange, network, address string, opts dialOptions) (net.Conn, error) {
+	size := ports.High - ports.Low + 1
+	offset := rand.IntN(size)
+	deadline := time.Now().Add(opts.timeout)
+
+	var lastErr error
+	for i := 0; i < size && time.Now().Before(deadline); i++ {
+		dialer := net.Dialer{
+			Deadline:  deadline,
+			LocalAddr: &net.TCPAddr{Port: ports.Low + (offset+i)%size},
+			Control:   chainControl(reuseAddr, markControl(opts)),
+		}
+		conn, err := dialer.Dial(network, address)
+		if err == nil {
+			return conn, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) && !errors.Is(err, syscall.EADDRNOTAVAIL) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("timed out")
+	}
+	return nil, fmt.Errorf("no free source port in %d-%d: %w", ports.Low, ports.High, lastErr)
+}
+
+// recordSourcePort stores the local port of conn when source ports are configured
+func (m *Monitor) recordSourcePort(result *PingResult, conn net.Conn) {
+	if m.SourcePorts == nil {
+		return
+	}
+	if addr, ok := conn.LocalAddr().(*net.TCPAddr); ok {
+		result.SourcePort = addr.Port
+	}
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"time"
+)
+
+// HostState tracks the live status of a single monitored host
+type HostState struct {
+	LastResult          *PingResult `json:"last_result,omitempty"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	Misconfigured       bool        `json:"misconfigured,omitempty"` // never resolved; probed rarely if at all
+}
+
+// State is a point-in-time snapshot of the monitor's internal state
+type State struct {
+	Hosts       map[string]HostState `json:"hosts"`
+	Cycles      int64                `json:"cycles"`
+	LastCycle   *time.Time           `json:"last_cycle,omitempty"`
+	OutageStart *time.Time           `json:"outage_start,omitempty"` // set while all hosts are failing
+	Restored    bool                 `json:"restored,omitempty"`     // loaded from a snapshot; no cycle has run since
+}
+
+// record updates the live state with the results of a completed cycle and
+// returns the latest result of every monitored host, which up/down is
+// decided on
+func (m *Monitor) record(results []PingResult) []PingResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock().Now()
+
+	// The first real cycle replaces any restored snapshot, dropping hosts
+	// that are no longer monitored and stale failure counts
+	if m.restored {
+		m.state = make(map[string]HostState, len(results))
+		m.restored = false
+	}
+
+	for _, result := range results {
+		state := m.state[result.Host]
+		r := result
+		state.LastResult = &r
+		if result.Success {
+			state.ConsecutiveFailures = 0
+		} else {
+			state.ConsecutiveFailures++
+		}
+		m.state[result.Host] = state
+	}
+
+	// Other hosts aren't in this cycle, so the outage is judged on every
+	// host's latest result
+	latest := make([]PingResult, 0, len(m.hosts))
+	for _, host := range m.hosts {
+		if state, ok := m.state[host]; ok && state.LastResult != nil {
+			latest = append(latest, *state.LastResult)
+		}
+	}
+	if online, _ := IsInternetUp(latest, m.UpCriteria); !online && len(latest) > 0 {
+		if m.outageStart == nil {
+			m.outageStart = &now
+		}
+	} else {
+		m.outageStart = nil
+	}
+
+	m.cycles++
+	m.lastCycle = &now
+	return latest
+}
+
+// Snapshot returns a consistent copy of the monitor's live state
+func (m *Monitor) Snapshot() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hosts := make(map[string]HostState, len(m.state))
+	for host, state := range m.state {
+		if state.LastResult != nil {
+			r := *state.LastResult
+			state.LastResult = &r
+		}
+		state.Misconfigured = m.isMisconfigured(host)
+		hosts[host] = state
+	}
+
+	snapshot := State{
+		Hosts:    hosts,
+		Cycles:   m.cycles,
+		Restored: m.restored,
+	}
+	if m.lastCycle != nil {
+		t := *m.lastCycle
+		snapshot.LastCycle = &t
+	}
+	if m.outageStart != nil {
+		t := *m.outageStart
+		snapshot.OutageStart = &t
+	}
+	return snapshot
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package monitor
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setTOS is unsupported where the socket options aren't available
+func setTOS(network string, c syscall.RawConn, tos int) error {
+	return errors.New("not supported on this platform")
+}
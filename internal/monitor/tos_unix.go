@@ -0,0 +1,25 @@
+//go:build unix
+
+package monitor
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setTOS sets the IPv4 TOS byte or IPv6 traffic class of a socket
+func setTOS(network string, c syscall.RawConn, tos int) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if network == "tcp6" {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_TCLASS, tos)
+		} else {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS, tos)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
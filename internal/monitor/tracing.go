@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startCycleSpan starts the span covering one cycle; the span is nil when
+// tracing is disabled
+func (m *Monitor) startCycleSpan(hosts int) (context.Context, trace.Span) {
+	if m.Tracer == nil {
+		return context.Background(), nil
+	}
+	return m.Tracer.Start(context.Background(), "monitrix.cycle",
+		trace.WithAttributes(attribute.Int("monitrix.hosts", hosts)))
+}
+
+// endCycleSpan records the cycle's outcome and ends its span
+func endCycleSpan(span trace.Span, online bool, failed int) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(
+		attribute.Bool("monitrix.online", online),
+		attribute.Int("monitrix.failed_hosts", failed),
+	)
+	if !online {
+		span.SetStatus(codes.Error, "internet offline")
+	}
+	span.End()
+}
+
+// tracedPing probes host in a child span of ctx carrying the result's
+// outcome and its DNS and connect timings
+func (m *Monitor) tracedPing(ctx context.Context, host string) PingResult {
+	if m.Tracer == nil {
+		return m.safePing(host)
+	}
+
+	_, span := m.Tracer.Start(ctx, "monitrix.probe",
+		trace.WithAttributes(attribute.String("monitrix.host", host)))
+	defer span.End()
+
+	result := m.safePing(host)
+	span.SetAttributes(
+		attribute.Bool("monitrix.success", result.Success),
+		attribute.Int64("monitrix.latency_ms", result.Latency),
+		attribute.Int64("monitrix.dns_latency_ms", result.DNSLatency),
+		attribute.Int64("monitrix.connect_latency_ms", result.ConnectLatency),
+	)
+	if result.Port != "" {
+		span.SetAttributes(attribute.String("monitrix.port", result.Port))
+	}
+	if result.Attempts > 0 {
+		span.SetAttributes(attribute.Int("monitrix.attempts", result.Attempts))
+	}
+	if !result.Success {
+		span.SetAttributes(attribute.String("monitrix.error", result.Error))
+		span.SetStatus(codes.Error, result.Error)
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package monitor
+
+// UpRule selects how a cycle's results decide whether the internet is up
+type UpRule string
+
+const (
+	// UpRuleHosts treats the internet as up when at least UpCriteria.MinHosts
+	// hosts responded (one by default)
+	UpRuleHosts UpRule = "hosts"
+	// UpRuleAll treats the internet as up only when every host responded
+	UpRuleAll UpRule = "all"
+	// UpRuleProbes treats the internet as up when the share of successful
+	// probes across all hosts exceeds UpCriteria.Threshold. Checks with
+	// several probes (ProbesPerCheck) count each probe.
+	UpRuleProbes UpRule = "probes"
+)
+
+// RefusedPolicy decides how a refused connection counts: the host is
+// reachable but nothing is listening on the probed port
+type RefusedPolicy string
+
+const (
+	// RefusedDown counts a refused connection as a failure (the default)
+	RefusedDown RefusedPolicy = "down"
+	// RefusedUp counts it as a success: the network path works
+	RefusedUp RefusedPolicy = "up"
+	// RefusedDegraded counts it towards the internet being up and reports
+	// the host as degraded (see DegradedHosts) rather than failed
+	RefusedDegraded RefusedPolicy = "degraded"
+)
+
+// ValidRefusedPolicy reports whether policy is a known refused policy
+func ValidRefusedPolicy(policy RefusedPolicy) bool {
+	return policy == RefusedDown || policy == RefusedUp || policy == RefusedDegraded
+}
+
+// UpCriteria configures IsInternetUp
+type UpCriteria struct {
+	Rule      UpRule
+	MinHosts  int     // successful hosts required under UpRuleHosts; values below 1 mean 1
+	Threshold float64 // percentage of probes that must succeed under UpRuleProbes
+
+	// Refused sets how refused connections count, overridden per host by
+	// HostRefused; empty means RefusedDown
+	Refused     RefusedPolicy
+	HostRefused map[string]RefusedPolicy
+}
+
+// refusedPolicy returns the refused policy for host
+func (c UpCriteria) refusedPolicy(host string) RefusedPolicy {
+	if policy, ok := c.HostRefused[host]; ok && policy != "" {
+		return policy
+	}
+	if c.Refused != "" {
+		return c.Refused
+	}
+	return RefusedDown
+}
+
+// degraded reports whether result is a refused connection that counts as
+// degraded under the criteria
+func (c UpCriteria) degraded(result PingResult) bool {
+	return !result.Success && result.Code() == CodeRefused && c.refusedPolicy(result.Host) == RefusedDegraded
+}
+
+// DegradedHosts returns the hosts whose refused connections the criteria
+// count as degraded. IsInternetUp counts them as up and leaves them out of
+// its failed hosts.
+func DegradedHosts(results []PingResult, criteria UpCriteria) []string {
+	var hosts []string
+	for _, result := range results {
+		if !result.Paused && !result.WarmingUp && criteria.degraded(result) {
+			hosts = append(hosts, result.Host)
+		}
+	}
+	return hosts
+}
+
+// IsInternetUp decides whether the internet was up for one cycle's results
+// and returns the hosts that failed. Paused placeholders and failures of
+// warming-up hosts are ignored, and refused connections count as the
+// criteria's RefusedPolicy says; degraded hosts are not reported as failed.
+func IsInternetUp(results []PingResult, criteria UpCriteria) (bool, []string) {
+	var probed, succeeded, warming int
+	var probesSent, probesAnswered int
+	var failedHosts []string
+
+	for _, result := range results {
+		if result.Paused {
+			continue
+		}
+		if result.WarmingUp && !result.Success {
+			warming++
+			continue
+		}
+		probed++
+		sent, answered := 1, 0
+		if result.ProbesSent > 0 {
+			sent, answered = result.ProbesSent, result.ProbesReceived
+		} else if result.Success {
+			answered = 1
+		}
+		probesSent += sent
+		switch {
+		case result.Success:
+			succeeded++
+			probesAnswered += answered
+		case result.Code() == CodeRefused && criteria.refusedPolicy(result.Host) == RefusedUp:
+			succeeded++
+			probesAnswered += sent
+		case criteria.degraded(result):
+			succeeded++
+			probesAnswered += sent
+		default:
+			probesAnswered += answered
+			failedHosts = append(failedHosts, result.Host)
+		}
+	}
+
+	// Only warming-up hosts were probed: no evidence of an outage
+	if probed == 0 && warming > 0 {
+		return true, nil
+	}
+
+	switch criteria.Rule {
+	case UpRuleAll:
+		return probed > 0 && succeeded == probed, failedHosts
+	case UpRuleProbes:
+		if probesSent == 0 {
+			return false, failedHosts
+		}
+		return float64(probesAnswered)/float64(probesSent)*100 > criteria.Threshold, failedHosts
+	default:
+		minHosts := criteria.MinHosts
+		if minHosts < 1 {
+			minHosts = 1
+		}
+		return succeeded >= minHosts, failedHosts
+	}
+}
@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsInternetUp(t *testing.T) {
+	up := func(host string) PingResult { return PingResult{Host: host, Success: true} }
+	down := func(host string) PingResult { return PingResult{Host: host, Error: "i/o timeout"} }
+	refused := func(host string) PingResult { return PingResult{Host: host, Error: "connection refused"} }
+	probes := func(host string, sent, received int) PingResult {
+		return PingResult{Host: host, Success: received > 0, ProbesSent: sent, ProbesReceived: received}
+	}
+
+	tests := []struct {
+		name     string
+		criteria UpCriteria
+		results  []PingResult
+		want     bool
+		failed   []string
+	}{
+		{"any: one of three up", UpCriteria{Rule: UpRuleHosts}, []PingResult{down("a"), up("b"), down("c")}, true, []string{"a", "c"}},
+		{"any: all down", UpCriteria{Rule: UpRuleHosts}, []PingResult{down("a"), down("b")}, false, []string{"a", "b"}},
+		{"any: default rule", UpCriteria{}, []PingResult{down("a"), up("b")}, true, []string{"a"}},
+		{"any: no results", UpCriteria{Rule: UpRuleHosts}, nil, false, nil},
+		{"min hosts met", UpCriteria{Rule: UpRuleHosts, MinHosts: 2}, []PingResult{up("a"), up("b"), down("c")}, true, []string{"c"}},
+		{"min hosts missed", UpCriteria{Rule: UpRuleHosts, MinHosts: 2}, []PingResult{up("a"), down("b"), down("c")}, false, []string{"b", "c"}},
+		{"all: every host up", UpCriteria{Rule: UpRuleAll}, []PingResult{up("a"), up("b")}, true, nil},
+		{"all: one host down", UpCriteria{Rule: UpRuleAll}, []PingResult{up("a"), down("b")}, false, []string{"b"}},
+		{"all: no results", UpCriteria{Rule: UpRuleAll}, nil, false, nil},
+		{"threshold: above", UpCriteria{Rule: UpRuleProbes, Threshold: 50}, []PingResult{up("a"), up("b"), down("c")}, true, []string{"c"}},
+		{"threshold: exactly at it is down", UpCriteria{Rule: UpRuleProbes, Threshold: 50}, []PingResult{up("a"), down("b")}, false, []string{"b"}},
+		{"threshold: counts probes, not hosts", UpCriteria{Rule: UpRuleProbes, Threshold: 50}, []PingResult{probes("a", 4, 1), probes("b", 4, 1)}, false, nil},
+		{"threshold: lossy host still answers", UpCriteria{Rule: UpRuleProbes, Threshold: 50}, []PingResult{probes("a", 4, 3), probes("b", 4, 0)}, false, []string{"b"}},
+		{"threshold: no probes sent", UpCriteria{Rule: UpRuleProbes, Threshold: 50}, nil, false, nil},
+		{"refused down by default", UpCriteria{Rule: UpRuleAll}, []PingResult{up("a"), refused("b")}, false, []string{"b"}},
+		{"refused up", UpCriteria{Rule: UpRuleAll, Refused: RefusedUp}, []PingResult{up("a"), refused("b")}, true, nil},
+		{"refused degraded", UpCriteria{Rule: UpRuleAll, Refused: RefusedDegraded}, []PingResult{up("a"), refused("b")}, true, nil},
+		{"refused per host", UpCriteria{Rule: UpRuleAll, HostRefused: map[string]RefusedPolicy{"b": RefusedUp}}, []PingResult{refused("a"), refused("b")}, false, []string{"a"}},
+		{"paused ignored", UpCriteria{Rule: UpRuleAll}, []PingResult{up("a"), {Host: "b", Paused: true}}, true, nil},
+		{"warming up ignored", UpCriteria{Rule: UpRuleAll}, []PingResult{up("a"), {Host: "b", Error: "i/o timeout", WarmingUp: true}}, true, nil},
+		{"only warming up", UpCriteria{Rule: UpRuleAll}, []PingResult{{Host: "a", Error: "i/o timeout", WarmingUp: true}}, true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, failed := IsInternetUp(test.results, test.criteria)
+			if got != test.want {
+				t.Errorf("up = %v, want %v", got, test.want)
+			}
+			if !slices.Equal(failed, test.failed) {
+				t.Errorf("failed hosts = %v, want %v", failed, test.failed)
+			}
+		})
+	}
+}
+
+func TestDegradedHosts(t *testing.T) {
+	up := PingResult{Host: "a", Success: true}
+	refused := PingResult{Host: "b", Error: "connection refused"}
+	down := PingResult{Host: "c", Error: "i/o timeout"}
+	criteria := UpCriteria{Rule: UpRuleAll, Refused: RefusedDegraded}
+
+	tests := []struct {
+		name     string
+		results  []PingResult
+		want     bool
+		failed   []string
+		degraded []string
+	}{
+		{"degraded host alone keeps all up", []PingResult{up, refused}, true, nil, []string{"b"}},
+		{"degraded next to a failure", []PingResult{up, refused, down}, false, []string{"c"}, []string{"b"}},
+		{"warming up not degraded", []PingResult{up, {Host: "b", Error: "connection refused", WarmingUp: true}}, true, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, failed := IsInternetUp(test.results, criteria)
+			if got != test.want || !slices.Equal(failed, test.failed) {
+				t.Errorf("up = %v, failed = %v; want %v, %v", got, failed, test.want, test.failed)
+			}
+			if degraded := DegradedHosts(test.results, criteria); !slices.Equal(degraded, test.degraded) {
+				t.Errorf("degraded = %v, want %v", degraded, test.degraded)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+)
+
+// Connection states recorded on HTTP results when WarmConnections is enabled
+const (
+	ConnectionWarm = "warm"
+	ConnectionCold = "cold"
+)
+
+// warmTransport returns the host's pooled HTTP transport, which keeps one
+// idle connection open between cycles. The idle timeout outlasts the
+// host's interval so the next probe finds it still open.
+func (m *Monitor) warmTransport(host string, dial func(ctx context.Context, network, address string) (net.Conn, error)) *http.Transport {
+	m.warmMu.Lock()
+	defer m.warmMu.Unlock()
+
+	if transport, ok := m.warm[host]; ok {
+		return transport
+	}
+	transport := &http.Transport{
+		DialContext:         dial,
+		DisableCompression:  true,
+		MaxIdleConnsPerHost: 1,
+		IdleConnTimeout:     2 * m.intervalFor(host),
+	}
+	m.warm[host] = transport
+	return transport
+}
+
+// traceConnection records on result whether the request went over a
+// pooled connection (warm) or had to open one (cold)
+func traceConnection(ctx context.Context, result *PingResult) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			result.Connection = ConnectionCold
+			if info.Reused {
+				result.Connection = ConnectionWarm
+			}
+		},
+	})
+}
+
+// closeWarm closes all pooled connections
+func (m *Monitor) closeWarm() {
+	m.warmMu.Lock()
+	defer m.warmMu.Unlock()
+
+	for host, transport := range m.warm {
+		transport.CloseIdleConnections()
+		delete(m.warm, host)
+	}
+}
@@ -0,0 +1,40 @@
+package monitor
+
+// startWarmup marks hosts missing from a restored snapshot as newly added;
+// their failures are excluded from up/down decisions for WarmupGrace or
+// until they first succeed. Called with m.mu held.
+func (m *Monitor) startWarmup(known map[string]HostState) {
+	if m.WarmupGrace <= 0 {
+		return
+	}
+	deadline := m.clock().Now().Add(m.WarmupGrace)
+	for _, host := range m.hosts {
+		if _, ok := known[host]; !ok {
+			m.warmup[host] = deadline
+		}
+	}
+}
+
+// markWarmingUp flags failures of hosts still within their warm-up window
+func (m *Monitor) markWarmingUp(results []PingResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.warmup) == 0 {
+		return
+	}
+	now := m.clock().Now()
+	for i := range results {
+		deadline, ok := m.warmup[results[i].Host]
+		if !ok {
+			continue
+		}
+		if results[i].Success || now.After(deadline) {
+			delete(m.warmup, results[i].Host)
+			continue
+		}
+		if !results[i].Paused {
+			results[i].WarmingUp = true
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// Batch merges result slices arriving on in within window of the first one
+// into a single slice, so results delivered piecemeal are stored as one
+// entry. A batch is sent early when a host would appear in it twice, as an
+// entry holds at most one result per host, so no result waits longer than
+// window. The returned channel is closed after in is closed and drained.
+// A non-positive window returns in unchanged.
+func Batch(in <-chan []monitor.PingResult, window time.Duration) <-chan []monitor.PingResult {
+	if window <= 0 {
+		return in
+	}
+
+	out := make(chan []monitor.PingResult, cap(in))
+	go func() {
+		defer close(out)
+
+		var batch []monitor.PingResult
+		seen := make(map[string]bool)
+		var deadline <-chan time.Time
+
+		flush := func() {
+			if len(batch) > 0 {
+				out <- batch
+			}
+			batch = nil
+			seen = make(map[string]bool)
+			deadline = nil
+		}
+
+		for {
+			select {
+			case results, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				for _, result := range results {
+					if seen[result.Host] {
+						flush()
+						break
+					}
+				}
+				if len(results) == 0 {
+					continue
+				}
+				if batch == nil {
+					deadline = time.After(window)
+				}
+				for _, result := range results {
+					seen[result.Host] = true
+				}
+				batch = append(batch, results...)
+			case <-deadline:
+				flush()
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"os"
+	"strings"
+
+	"monitrix/internal/monitor"
+)
+
+// dropHosts removes results for the "|"-separated hosts in arg, e.g. to
+// keep internal targets out of shared storage
+func dropHosts(arg string) (Transformer, error) {
+	hosts := make(map[string]bool)
+	for _, host := range strings.Split(arg, "|") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts[host] = true
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("expected hosts, e.g. drop=10.0.0.1|db.local")
+	}
+
+	return func(results []monitor.PingResult) []monitor.PingResult {
+		kept := make([]monitor.PingResult, 0, len(results))
+		for _, result := range results {
+			if !hosts[result.Host] {
+				kept = append(kept, result)
+			}
+		}
+		return kept
+	}, nil
+}
+
+// anonymizeHosts replaces host names with stable pseudonyms derived from a
+// hash of arg (an optional salt) and the host, and drops resolved addresses
+// and DNS answers, which would identify the host anyway
+func anonymizeHosts(salt string) (Transformer, error) {
+	return func(results []monitor.PingResult) []monitor.PingResult {
+		anonymized := make([]monitor.PingResult, len(results))
+		for i, result := range results {
+			sum := sha256.Sum256([]byte(salt + result.Host))
+			result.Host = "host-" + hex.EncodeToString(sum[:4])
+			result.Addresses = nil
+			result.Records = nil
+			anonymized[i] = result
+		}
+		return anonymized
+	}, nil
+}
+
+// tag returns result with key set to value in a copy of its tags, leaving
+// the tags of the result it was copied from untouched
+func tag(result monitor.PingResult, key, value string) monitor.PingResult {
+	tags := maps.Clone(result.Tags)
+	if tags == nil {
+		tags = make(map[string]string, 1)
+	}
+	tags[key] = value
+	result.Tags = tags
+	return result
+}
+
+// sessionTag tags every result with a session ID, arg or else a random one
+// chosen at startup, so results from different runs can be told apart
+func sessionTag(arg string) (Transformer, error) {
+	session := arg
+	if session == "" {
+		var id [4]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate session ID: %w", err)
+		}
+		session = hex.EncodeToString(id[:])
+	}
+
+	return func(results []monitor.PingResult) []monitor.PingResult {
+		tagged := make([]monitor.PingResult, len(results))
+		for i, result := range results {
+			tagged[i] = tag(result, "session", session)
+		}
+		return tagged
+	}, nil
+}
+
+// geoNetwork is a network and the location its addresses are tagged with
+type geoNetwork struct {
+	prefix   netip.Prefix
+	location string
+}
+
+// geoLocate tags results with the location of the most specific network
+// containing the host's address, read from the file at arg. Each line is
+// "CIDR,location", e.g. "203.0.113.0/24,Frankfurt DE"; blank lines and
+// lines starting with # are skipped. A host given as an IP is matched
+// directly, any other by its resolved addresses (RecordAddresses).
+// Results with no matching address are left untagged.
+func geoLocate(path string) (Transformer, error) {
+	if path == "" {
+		return nil, errors.New("expected a CIDR,location file, e.g. geo=/etc/monitrix/geo.csv")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []geoNetwork
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cidr, location, ok := strings.Cut(line, ",")
+		location = strings.TrimSpace(location)
+		if !ok || location == "" {
+			return nil, fmt.Errorf("%s:%d: expected CIDR,location", path, i+1)
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		networks = append(networks, geoNetwork{prefix.Masked(), location})
+	}
+
+	locate := func(address string) (string, int) {
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return "", -1
+		}
+		addr = addr.Unmap()
+		location, bits := "", -1
+		for _, network := range networks {
+			if network.prefix.Bits() > bits && network.prefix.Contains(addr) {
+				location, bits = network.location, network.prefix.Bits()
+			}
+		}
+		return location, bits
+	}
+
+	return func(results []monitor.PingResult) []monitor.PingResult {
+		located := make([]monitor.PingResult, len(results))
+		for i, result := range results {
+			located[i] = result
+			best, bestBits := "", -1
+			for _, address := range append([]string{result.Host}, result.Addresses...) {
+				if location, bits := locate(address); bits > bestBits {
+					best, bestBits = location, bits
+				}
+			}
+			if bestBits >= 0 {
+				located[i] = tag(result, "geo", best)
+			}
+		}
+		return located
+	}, nil
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"monitrix/internal/monitor"
+)
+
+func TestGeoLocate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.csv")
+	table := "# network,location\n203.0.113.0/24,Frankfurt DE\n203.0.113.128/25,Berlin DE\n\n2001:db8::/32,Amsterdam NL\n"
+	if err := os.WriteFile(path, []byte(table), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := Parse("geo=" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := []monitor.PingResult{
+		{Host: "203.0.113.10"},
+		{Host: "203.0.113.200"},
+		{Host: "cdn.example", Addresses: []string{"192.0.2.1", "2001:db8::1"}},
+		{Host: "elsewhere.example", Addresses: []string{"192.0.2.1"}},
+		{Host: "::ffff:203.0.113.10", Tags: map[string]string{"session": "s1"}},
+	}
+	want := []string{"Frankfurt DE", "Berlin DE", "Amsterdam NL", "", "Frankfurt DE"}
+
+	output := chain.Apply(input)
+	for i, result := range output {
+		if got := result.Tags["geo"]; got != want[i] {
+			t.Errorf("%s: geo = %q, want %q", result.Host, got, want[i])
+		}
+	}
+	if output[4].Tags["session"] != "s1" {
+		t.Errorf("existing tags lost: %v", output[4].Tags)
+	}
+	if _, ok := input[4].Tags["geo"]; ok {
+		t.Error("geo modified the input's tags")
+	}
+}
+
+func TestGeoLocateRejectsBadTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.csv")
+	if err := os.WriteFile(path, []byte("203.0.113.0/33,Nowhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{"geo", "geo=" + path, "geo=" + path + ".missing"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestSessionTag(t *testing.T) {
+	chain, err := Parse("session=nightly-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := chain.Apply([]monitor.PingResult{{Host: "a"}, {Host: "b"}})
+	for _, result := range results {
+		if result.Tags["session"] != "nightly-1" {
+			t.Errorf("%s: session = %q, want nightly-1", result.Host, result.Tags["session"])
+		}
+	}
+
+	// Without a name the ID is random but the same for every cycle of a run
+	chain, err = Parse("session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := chain.Apply([]monitor.PingResult{{Host: "a"}})[0].Tags["session"]
+	second := chain.Apply([]monitor.PingResult{{Host: "a"}})[0].Tags["session"]
+	if first == "" || first != second {
+		t.Errorf("session IDs %q and %q, want one non-empty ID", first, second)
+	}
+}
@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"monitrix/internal/monitor"
+)
+
+// Transformer post-processes a cycle's results before they are stored.
+// It must not modify the slice it is given; return a new one instead.
+type Transformer func([]monitor.PingResult) []monitor.PingResult
+
+// Factory builds a transformer from its configuration argument (the part
+// after "=" in a spec, empty when absent)
+type Factory func(arg string) (Transformer, error)
+
+// Chain applies transformers in order
+type Chain []Transformer
+
+// Apply runs results through every transformer in the chain
+func (c Chain) Apply(results []monitor.PingResult) []monitor.PingResult {
+	for _, transform := range c {
+		results = transform(results)
+	}
+	return results
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"drop":      dropHosts,
+		"anonymize": anonymizeHosts,
+		"geo":       geoLocate,
+		"session":   sessionTag,
+	}
+)
+
+// Register makes a transformer available to Parse under name, replacing
+// any existing registration
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Names returns the registered transformer names, sorted
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse builds a chain from a comma-separated spec such as
+// "drop=10.0.0.1|10.0.0.2,anonymize". An empty spec gives an empty chain.
+func Parse(spec string) (Chain, error) {
+	var chain Chain
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+
+		registryMu.RLock()
+		factory, ok := registry[name]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown transformer %q (available: %s)", name, strings.Join(Names(), ", "))
+		}
+
+		transform, err := factory(strings.TrimSpace(arg))
+		if err != nil {
+			return nil, fmt.Errorf("transformer %s: %w", name, err)
+		}
+		chain = append(chain, transform)
+	}
+	return chain, nil
+}
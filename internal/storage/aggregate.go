@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// EntryAggregate describes the cycles rolled up into one log entry
+type EntryAggregate struct {
+	Start        time.Time `json:"start"` // timestamp of the first cycle in the window
+	Cycles       int       `json:"cycles"`
+	OnlineCycles int       `json:"online_cycles"`
+	PausedCycles int       `json:"paused_cycles"`
+
+	// DowntimeSeconds is how long the internet was down within a daily
+	// summary (see SummarizeOlderThan), whose cycles are otherwise unordered
+	DowntimeSeconds int64 `json:"downtime_seconds,omitempty"`
+}
+
+// EntrySink stores complete log entries
+type EntrySink interface {
+	SaveEntry(entry LogEntry) error
+	Close() error
+}
+
+// Aggregator rolls every window of cycles into a single summary entry before
+// passing it to the underlying storage, trading resolution for space. Each
+// host is probed on its own ticker, so a window closes once the most often
+// probed host has been in window cycles.
+type Aggregator struct {
+	target   EntrySink
+	window   int
+	criteria monitor.UpCriteria
+
+	mu      sync.Mutex
+	start   time.Time
+	cycles  int
+	online  int
+	paused  int
+	hosts   []string
+	results map[string]*monitor.PingResult
+	sums    map[string]int64 // trusted latency totals per host
+	rounds  map[string]int   // cycles each host was in this window
+	latest  monitor.LatestResults
+}
+
+// NewAggregator creates an aggregator writing one entry per window cycles.
+// criteria decides which cycles count as online; interval is the longest
+// probe interval of any host (see monitor.LatestResults).
+func NewAggregator(target EntrySink, window int, criteria monitor.UpCriteria, interval time.Duration) *Aggregator {
+	return &Aggregator{
+		target:   target,
+		window:   window,
+		criteria: criteria,
+		results:  make(map[string]*monitor.PingResult),
+		sums:     make(map[string]int64),
+		rounds:   make(map[string]int),
+		latest:   monitor.LatestResults{Interval: interval},
+	}
+}
+
+// Save adds a cycle to the current window, writing the summary once it is full
+func (a *Aggregator) Save(results []monitor.PingResult) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.save(results, time.Now())
+}
+
+// save adds a cycle that completed at now
+func (a *Aggregator) save(results []monitor.PingResult, now time.Time) error {
+	if a.cycles == 0 {
+		a.start = now
+	}
+	a.cycles++
+
+	paused := len(results) > 0
+	for _, result := range results {
+		if !result.Paused {
+			paused = false
+		}
+	}
+	// Judged with the other hosts' latest results, as the cycle only holds
+	// some of them
+	latest := a.latest.Update(results)
+	if paused {
+		a.paused++
+	} else if online, _ := monitor.IsInternetUp(latest, a.criteria); online {
+		a.online++
+	}
+
+	full := false
+	for _, result := range results {
+		a.add(result)
+		a.rounds[result.Host]++
+		full = full || a.rounds[result.Host] >= a.window
+	}
+
+	if full {
+		return a.flush(now)
+	}
+	return nil
+}
+
+// add folds one host's result into its running summary
+func (a *Aggregator) add(result monitor.PingResult) {
+	summary, ok := a.results[result.Host]
+	if !ok {
+		summary = &monitor.PingResult{
+			Host:      result.Host,
+			Paused:    true,
+			Aggregate: &monitor.ResultAggregate{},
+		}
+		a.results[result.Host] = summary
+		a.hosts = append(a.hosts, result.Host)
+	}
+	if result.Tags != nil {
+		summary.Tags = result.Tags // the latest transformer tags
+	}
+	if result.Paused {
+		return
+	}
+
+	agg := summary.Aggregate
+	summary.Paused = false
+	agg.Cycles++
+	if result.Success {
+		agg.Successes++
+	} else if result.Error != "" {
+		summary.Error = result.Error
+		summary.ErrorCode = result.ErrorCode
+	}
+
+	if result.LatencyTrusted() {
+		if agg.LatencySamples == 0 || result.Latency < agg.MinLatency {
+			agg.MinLatency = result.Latency
+		}
+		if result.Latency > agg.MaxLatency {
+			agg.MaxLatency = result.Latency
+		}
+		a.sums[result.Host] += result.Latency
+		agg.LatencySamples++
+	}
+}
+
+// addRollup folds a roll-up entry's cycles and host summaries into the
+// current window
+func (a *Aggregator) addRollup(entry LogEntry) {
+	if a.cycles == 0 {
+		a.start = entry.Aggregate.Start
+	}
+	a.cycles += entry.Aggregate.Cycles
+	a.online += entry.Aggregate.OnlineCycles
+	a.paused += entry.Aggregate.PausedCycles
+
+	for _, result := range entry.Results {
+		if result.Aggregate == nil {
+			continue
+		}
+		a.add(monitor.PingResult{Host: result.Host, Paused: true, Tags: result.Tags})
+		summary, from := a.results[result.Host], result.Aggregate
+		if result.Paused {
+			continue
+		}
+		if !result.Success || from.Successes < from.Cycles {
+			summary.Error, summary.ErrorCode = result.Error, result.ErrorCode
+		}
+		summary.Paused = false
+
+		agg := summary.Aggregate
+		if from.LatencySamples > 0 {
+			if agg.LatencySamples == 0 || from.MinLatency < agg.MinLatency {
+				agg.MinLatency = from.MinLatency
+			}
+			agg.MaxLatency = max(agg.MaxLatency, from.MaxLatency)
+		}
+		agg.Cycles += from.Cycles
+		agg.Successes += from.Successes
+		agg.LatencySamples += from.LatencySamples
+		a.sums[result.Host] += result.Latency * int64(from.LatencySamples)
+	}
+}
+
+// flush writes the current window and starts a new one
+func (a *Aggregator) flush(now time.Time) error {
+	if a.cycles == 0 {
+		return nil
+	}
+	return a.target.SaveEntry(a.take(now))
+}
+
+// take returns the current window as an entry stamped now and starts a new one
+func (a *Aggregator) take(now time.Time) LogEntry {
+	results := make([]monitor.PingResult, 0, len(a.hosts))
+	for _, host := range a.hosts {
+		summary := a.results[host]
+		summary.Timestamp = now
+		summary.Success = summary.Aggregate.Successes*2 > summary.Aggregate.Cycles
+		if samples := summary.Aggregate.LatencySamples; samples > 0 {
+			summary.Latency = a.sums[host] / int64(samples)
+		}
+		results = append(results, *summary)
+	}
+
+	entry := LogEntry{
+		Timestamp: now,
+		Results:   results,
+		Aggregate: &EntryAggregate{
+			Start:        a.start,
+			Cycles:       a.cycles,
+			OnlineCycles: a.online,
+			PausedCycles: a.paused,
+		},
+	}
+
+	a.cycles, a.online, a.paused = 0, 0, 0
+	a.hosts = nil
+	a.results = make(map[string]*monitor.PingResult)
+	a.sums = make(map[string]int64)
+	a.rounds = make(map[string]int)
+	return entry
+}
+
+// Close writes any partial window and closes the underlying storage
+func (a *Aggregator) Close() error {
+	a.mu.Lock()
+	err := a.flush(time.Now())
+	a.mu.Unlock()
+
+	if closeErr := a.target.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// activeFiles holds the paths of log files currently open for writing by a
+// FileStorage, which CompressClosed must leave alone
+var activeFiles sync.Map
+
+// logFiles lists the daily log files in dataDir, plain or gzipped, in date
+// order. When a day has both, the plain file is the complete one (its
+// compression was interrupted) and the gzipped copy is left out.
+func logFiles(dataDir string) ([]string, error) {
+	plain, err := filepath.Glob(filepath.Join(dataDir, "network_monitor_*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	gzipped, err := filepath.Glob(filepath.Join(dataDir, "network_monitor_*.jsonl.gz"))
+	if err != nil {
+		return nil, err
+	}
+
+	files := plain
+	for _, path := range gzipped {
+		if _, err := os.Stat(strings.TrimSuffix(path, ".gz")); err == nil {
+			continue
+		}
+		files = append(files, path)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return filepath.Base(files[i]) < filepath.Base(files[j])
+	})
+	return files, nil
+}
+
+// openLogFile opens a log file for reading, decompressing .gz files
+func openLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil || !strings.HasSuffix(path, ".gz") {
+		return file, err
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+// CompressClosed gzips every daily log file in dataDir other than today's
+// and any open for writing, replacing each with a .jsonl.gz, and returns
+// how many were compressed. The archive is written to a temporary file and
+// renamed into place before the original is removed, so an interrupted run
+// leaves the original intact to be compressed again next time.
+func CompressClosed(dataDir string) (int, error) {
+	// Leftovers from an interrupted run are incomplete
+	stale, _ := filepath.Glob(filepath.Join(dataDir, "network_monitor_*.jsonl.gz.tmp"))
+	for _, path := range stale {
+		os.Remove(path)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dataDir, "network_monitor_*.jsonl"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	compressed := 0
+	for _, path := range files {
+		day, ok := logFileDate(filepath.Base(path))
+		if !ok || day.Format("2006-01-02") >= today {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			if _, open := activeFiles.Load(abs); open {
+				continue
+			}
+		}
+		if err := compressFile(path); err != nil {
+			return compressed, err
+		}
+		compressed++
+	}
+	return compressed, nil
+}
+
+// compressFile replaces path with a gzipped path+".gz"
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	tmpPath := path + ".gz.tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmpPath, err)
+	}
+	zw := gzip.NewWriter(tmp)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s after compressing it: %w", path, err)
+	}
+	return nil
+}
@@ -1,120 +1,497 @@
 package storage
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
+	"monitrix/internal/clock"
 	"monitrix/internal/monitor"
 )
 
+// WriteMode selects how FileStorage handles its log file between writes
+type WriteMode string
+
+const (
+	// WriteHeldOpen keeps the file open and leaves flushing to the OS
+	WriteHeldOpen WriteMode = "held"
+	// WriteReopen opens, appends, fsyncs and closes the file on every write
+	WriteReopen WriteMode = "reopen"
+)
+
+// ValidWriteMode reports whether mode is a known write mode
+func ValidWriteMode(mode WriteMode) bool {
+	return mode == WriteHeldOpen || mode == WriteReopen
+}
+
 // FileStorage handles storing ping results to file
 type FileStorage struct {
+	dataDir  string
 	filePath string
+	day      string // date of filePath, YYYY-MM-DD
+	mode     WriteMode
 	mu       sync.Mutex
-	file     *os.File
+	file     *os.File // nil in WriteReopen mode
+
+	// Clock timestamps Save entries and picks the day's file; defaults to
+	// the real clock. NewFileStorageWithClock sets it before the first
+	// file is chosen.
+	Clock clock.Clock
+
+	// Buffered mode, see NewBufferedFileStorage
+	maxBatch int
+	pending  int
+	buffer   []byte
+	// maxBuffer caps the unwritten bytes kept while writes keep failing;
+	// entries that would exceed it are dropped
+	maxBuffer int
+	stop      chan struct{}
+	stopped   chan struct{}
 }
 
+// DefaultMaxBatch is the number of entries buffered before a flush when
+// NewBufferedFileStorage is given no limit
+const DefaultMaxBatch = 100
+
+// maxBufferBytes is how much unwritten log data is held in memory while the
+// log file can't be written, e.g. on a full disk, before new entries are
+// dropped
+const maxBufferBytes = 16 << 20
+
 // LogEntry represents a log entry in the file
 type LogEntry struct {
 	Timestamp time.Time            `json:"timestamp"`
 	Results   []monitor.PingResult `json:"results"`
+	Aggregate *EntryAggregate      `json:"aggregate,omitempty"` // set on roll-up records
+	Source    string               `json:"source,omitempty"`    // the peer an entry was read from; empty for local entries
 }
 
-// NewFileStorage creates a new file storage instance
-func NewFileStorage(dataDir string) (*FileStorage, error) {
+// NewFileStorage creates a new file storage instance. An empty mode means WriteHeldOpen.
+func NewFileStorage(dataDir string, mode WriteMode) (*FileStorage, error) {
+	return NewFileStorageWithClock(dataDir, mode, nil)
+}
+
+// NewFileStorageWithClock is NewFileStorage with the clock that timestamps
+// entries and picks the day's file, starting with the first one. A nil
+// clock means the real one.
+func NewFileStorageWithClock(dataDir string, mode WriteMode, clk clock.Clock) (*FileStorage, error) {
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	filename := fmt.Sprintf("network_monitor_%s.jsonl", time.Now().Format("2006-01-02"))
-	filePath := filepath.Join(dataDir, filename)
+	day := clk.Now().Format("2006-01-02")
+	filePath := logFilePath(dataDir, day)
 
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if mode == "" {
+		mode = WriteHeldOpen
+	}
+	if !ValidWriteMode(mode) {
+		return nil, fmt.Errorf("unknown write mode %q", mode)
+	}
+
+	// Opening up front surfaces permission problems at startup in either mode
+	file, err := openLog(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
+	if mode == WriteReopen {
+		file.Close()
+		file = nil
+	}
+
+	if abs, err := filepath.Abs(filePath); err == nil {
+		activeFiles.Store(abs, struct{}{})
+	}
 
 	return &FileStorage{
-		filePath: filePath,
-		file:     file,
+		dataDir:   dataDir,
+		filePath:  filePath,
+		day:       day,
+		mode:      mode,
+		file:      file,
+		Clock:     clk,
+		maxBuffer: maxBufferBytes,
 	}, nil
 }
 
+// NewBufferedFileStorage creates a file storage that keeps entries in memory
+// and appends them in one write once maxBatch have accumulated or
+// flushInterval has passed since the last flush. Close and ReadLogs flush
+// first, so nothing is lost on a graceful shutdown and reads see every entry.
+func NewBufferedFileStorage(dataDir string, flushInterval time.Duration, maxBatch int) (*FileStorage, error) {
+	fs, err := NewFileStorage(dataDir, WriteHeldOpen)
+	if err != nil {
+		return nil, err
+	}
+	if maxBatch <= 0 {
+		maxBatch = DefaultMaxBatch
+	}
+	fs.maxBatch = maxBatch
+	if flushInterval > 0 {
+		fs.stop = make(chan struct{})
+		fs.stopped = make(chan struct{})
+		go fs.flushEvery(flushInterval)
+	}
+	return fs, nil
+}
+
+// flushEvery flushes the buffer on every tick until Close
+func (fs *FileStorage) flushEvery(interval time.Duration) {
+	defer close(fs.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fs.mu.Lock()
+			if err := fs.flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to flush log buffer: %v\n", err)
+			}
+			fs.mu.Unlock()
+		case <-fs.stop:
+			return
+		}
+	}
+}
+
+// flush writes out the buffered entries. Whatever couldn't be written stays
+// buffered for the next attempt. Callers hold fs.mu.
+func (fs *FileStorage) flush() error {
+	if len(fs.buffer) == 0 {
+		return nil
+	}
+	n, err := writeFull(fs.file, fs.buffer)
+	fs.buffer = fs.buffer[n:]
+	if err != nil {
+		return fmt.Errorf("failed to write to log file: %w", err)
+	}
+	fs.buffer = fs.buffer[:0]
+	fs.pending = 0
+	return nil
+}
+
+// writeFull writes all of data, retrying writes that come back short
+// without an error, and returns how much was written
+func writeFull(w io.Writer, data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		n, err := w.Write(data[written:])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
+
+// logFilePath returns the path of the log file for a YYYY-MM-DD day
+func logFilePath(dataDir, day string) string {
+	return filepath.Join(dataDir, fmt.Sprintf("network_monitor_%s.jsonl", day))
+}
+
+// rotate switches to the log file for now's date when it differs from the
+// current one. Buffered entries are written to the old file first. Callers
+// hold fs.mu.
+func (fs *FileStorage) rotate(now time.Time) error {
+	day := now.Format("2006-01-02")
+	if day == fs.day {
+		return nil
+	}
+
+	// Whatever can't be written now goes to the new file instead
+	if len(fs.buffer) > 0 {
+		if err := fs.writeBuffer(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush log buffer before rotating: %v\n", err)
+		}
+	}
+
+	filePath := logFilePath(fs.dataDir, day)
+	if fs.mode == WriteHeldOpen {
+		file, err := openLog(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		if err := fs.file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close log file %s: %v\n", fs.filePath, err)
+		}
+		fs.file = file
+	}
+
+	if abs, err := filepath.Abs(fs.filePath); err == nil {
+		activeFiles.Delete(abs)
+	}
+	if abs, err := filepath.Abs(filePath); err == nil {
+		activeFiles.Store(abs, struct{}{})
+	}
+	fs.filePath = filePath
+	fs.day = day
+	return nil
+}
+
+// openLog opens path for appending, creating it if needed
+func openLog(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// clock returns the configured clock or the real one
+func (fs *FileStorage) clock() clock.Clock {
+	if fs.Clock == nil {
+		return clock.RealClock{}
+	}
+	return fs.Clock
+}
+
 // Save writes ping results to the log file
 func (fs *FileStorage) Save(results []monitor.PingResult) error {
+	return fs.SaveEntry(LogEntry{
+		Timestamp: fs.clock().Now(),
+		Results:   results,
+	})
+}
+
+// SaveEntry writes a complete log entry to the log file, moving on to a
+// new file when the date has changed since the last write
+func (fs *FileStorage) SaveEntry(entry LogEntry) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	entry := LogEntry{
-		Timestamp: time.Now(),
-		Results:   results,
-	}
-
 	data, err := json.Marshal(entry)
 	if err != nil {
 		return fmt.Errorf("failed to marshal log entry: %w", err)
 	}
+	if err := fs.rotate(fs.clock().Now()); err != nil {
+		return err
+	}
 
+	// Unbuffered writes go through the buffer too, so the rest of an entry
+	// cut short by a failed write is written ahead of the next one instead
+	// of leaving it merged with a half line
 	data = append(data, '\n')
-	if _, err := fs.file.Write(data); err != nil {
-		return fmt.Errorf("failed to write to log file: %w", err)
+	if len(fs.buffer)+len(data) > fs.maxBuffer {
+		// Try to write out the backlog first; if that still fails, the
+		// new entry is dropped rather than growing the buffer unbounded
+		if err := fs.writeBuffer(); err != nil {
+			return fmt.Errorf("log buffer full with %d unwritten bytes, dropping entry: %w", len(fs.buffer), err)
+		}
+	}
+	fs.buffer = append(fs.buffer, data...)
+	if fs.maxBatch > 0 {
+		fs.pending++
+		if fs.pending >= fs.maxBatch {
+			return fs.flush()
+		}
+		return nil
+	}
+	return fs.writeBuffer()
+}
+
+// BufferDepth returns how many entries and bytes are buffered and not yet
+// written, whether held by STORAGE_FLUSH_MS or left by failed writes
+func (fs *FileStorage) BufferDepth() (entries, size int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return bytes.Count(fs.buffer, []byte{'\n'}), len(fs.buffer)
+}
+
+// writeBuffer writes out the buffer the way the write mode calls for.
+// Callers hold fs.mu.
+func (fs *FileStorage) writeBuffer() error {
+	if fs.mode == WriteReopen {
+		return fs.appendDurably()
 	}
+	return fs.flush()
+}
 
+// appendDurably writes the buffer to the log file and syncs it to disk
+// before closing, so a write that returned nil survives a crash or power
+// loss. Callers hold fs.mu.
+func (fs *FileStorage) appendDurably() error {
+	file, err := openLog(fs.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	n, err := writeFull(file, fs.buffer)
+	fs.buffer = fs.buffer[n:]
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write to log file: %w", err)
+	}
+	fs.buffer = fs.buffer[:0]
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
 	return nil
 }
 
-// Close closes the log file
+// Close flushes any buffered entries and closes the log file
 func (fs *FileStorage) Close() error {
+	if fs.stop != nil {
+		close(fs.stop)
+		<-fs.stopped
+		fs.stop = nil
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
+	if abs, err := filepath.Abs(fs.filePath); err == nil {
+		activeFiles.Delete(abs)
+	}
 	if fs.file != nil {
-		return fs.file.Close()
+		err := fs.flush()
+		if closeErr := fs.file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	if len(fs.buffer) > 0 {
+		return fs.appendDurably()
 	}
 	return nil
 }
 
-// ReadLogs reads all log entries from files in the data directory
+// ReadLogs reads the log entries in the time range from the data directory
+func (fs *FileStorage) ReadLogs(startTime, endTime *time.Time) ([]LogEntry, error) {
+	if err := fs.flushForRead(); err != nil {
+		return nil, err
+	}
+	return ReadLogs(fs.dataDir, startTime, endTime)
+}
+
+// ScanLogs streams the log entries in the time range from the data directory
+func (fs *FileStorage) ScanLogs(startTime, endTime *time.Time, fn func(LogEntry)) error {
+	if err := fs.flushForRead(); err != nil {
+		return err
+	}
+	return ScanLogs(fs.dataDir, startTime, endTime, fn)
+}
+
+// flushForRead writes out buffered entries so a read includes them
+func (fs *FileStorage) flushForRead() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.flush()
+}
+
+// scanFile calls fn for each entry of one log file in the time range,
+// returning how many lines couldn't be parsed. Lines are read one at a time
+// so a corrupt one, such as the half-written line left by a crash, is
+// skipped without losing the entries after it.
+func scanFile(r io.Reader, startTime, endTime *time.Time, fn func(LogEntry)) (skipped int, err error) {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var entry LogEntry
+			if json.Unmarshal(line, &entry) != nil {
+				skipped++
+			} else if inTimeRange(entry.Timestamp, startTime, endTime) {
+				fn(entry)
+			}
+		}
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, err
+		}
+	}
+}
+
+// inTimeRange reports whether t is within the optional bounds, inclusive
+func inTimeRange(t time.Time, startTime, endTime *time.Time) bool {
+	return (startTime == nil || !t.Before(*startTime)) && (endTime == nil || !t.After(*endTime))
+}
+
+// reportedCorrupt holds the corrupt line count last reported for each log
+// file, so every read doesn't repeat the same warning
+var reportedCorrupt sync.Map
+
+// reportCorrupt warns about skipped lines in a log file when their number
+// has changed since the last warning
+func reportCorrupt(filePath string, skipped int) {
+	previous, _ := reportedCorrupt.Swap(filePath, skipped)
+	if skipped > 0 && previous != skipped {
+		fmt.Printf("Warning: skipped %d corrupt line(s) in %s\n", skipped, filePath)
+	}
+}
+
+// ReadLogs reads all log entries from files in the data directory, oldest
+// first. Files are read in date order, but overlapping files or a clock
+// step can leave entries out of order, and downtime detection depends on
+// it, so they are sorted by timestamp; entries with equal timestamps keep
+// their stored order.
 func ReadLogs(dataDir string, startTime, endTime *time.Time) ([]LogEntry, error) {
-	files, err := filepath.Glob(filepath.Join(dataDir, "network_monitor_*.jsonl"))
+	var allEntries []LogEntry
+	err := ScanLogs(dataDir, startTime, endTime, func(entry LogEntry) {
+		allEntries = append(allEntries, entry)
+	})
+	sort.SliceStable(allEntries, func(i, j int) bool {
+		return allEntries[i].Timestamp.Before(allEntries[j].Timestamp)
+	})
+	return allEntries, err
+}
+
+// ScanLogs calls fn for each log entry in the time range, file by file in
+// date order, without holding the whole range in memory. Unlike ReadLogs
+// it can't reorder entries, so any stored out of order are passed as
+// stored. Compressed .jsonl.gz files are read transparently. Days whose log
+// file was pruned by retention are read from their daily summary, first
+// (see SummarizeOlderThan).
+func ScanLogs(dataDir string, startTime, endTime *time.Time, fn func(LogEntry)) error {
+	files, err := logFiles(dataDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list log files: %w", err)
+		return fmt.Errorf("failed to list log files: %w", err)
 	}
 
-	var allEntries []LogEntry
+	rawDays := make(map[string]bool, len(files))
+	for _, filePath := range files {
+		if day, ok := logFileDate(filepath.Base(filePath)); ok {
+			rawDays[day.Format("2006-01-02")] = true
+		}
+	}
+	if err := scanSummaries(dataDir, func(entry LogEntry) {
+		if !rawDays[summaryDay(entry)] && inTimeRange(entry.Timestamp, startTime, endTime) {
+			fn(entry)
+		}
+	}); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
 
 	for _, filePath := range files {
-		file, err := os.Open(filePath)
+		file, err := openLogFile(filePath)
 		if err != nil {
 			fmt.Printf("Warning: failed to read file %s: %v\n", filePath, err)
 			continue
 		}
 
-		decoder := json.NewDecoder(file)
-
-		for decoder.More() {
-			var entry LogEntry
-			if err := decoder.Decode(&entry); err != nil {
-				continue
-			}
-
-			// Filter by time range if specified
-			if startTime != nil && entry.Timestamp.Before(*startTime) {
-				continue
-			}
-			if endTime != nil && entry.Timestamp.After(*endTime) {
-				continue
-			}
-
-			allEntries = append(allEntries, entry)
-		}
+		skipped, err := scanFile(file, startTime, endTime, fn)
 		file.Close()
+		if err != nil {
+			fmt.Printf("Warning: stopped reading %s early: %v\n", filePath, err)
+		}
+		reportCorrupt(filePath, skipped)
 	}
 
-	return allEntries, nil
+	return nil
 }
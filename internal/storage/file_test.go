@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monitrix/internal/clock"
+	"monitrix/internal/monitor"
+)
+
+func TestReadLogsSortsShuffledFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return base.Add(time.Duration(hours) * time.Hour) }
+
+	// Entries within a file are out of order, the files overlap, and a
+	// clock step put a later entry in the earlier day's file
+	files := map[string][]time.Time{
+		"2023-07-14": {at(20), at(2), at(30), at(10)},
+		"2023-07-15": {at(26), at(5), at(40)},
+		"2023-07-16": {at(1), at(48)},
+	}
+	for day, stamps := range files {
+		var data []byte
+		for _, stamp := range stamps {
+			line, err := json.Marshal(LogEntry{Timestamp: stamp, Results: []monitor.PingResult{{Host: day}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(append(data, line...), '\n')
+		}
+		if err := os.WriteFile(filepath.Join(dir, "network_monitor_"+day+".jsonl"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logs, err := ReadLogs(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 9 {
+		t.Fatalf("got %d entries, want 9", len(logs))
+	}
+	for i := 1; i < len(logs); i++ {
+		if logs[i].Timestamp.Before(logs[i-1].Timestamp) {
+			t.Fatalf("entry %d (%v) comes after %v", i, logs[i].Timestamp, logs[i-1].Timestamp)
+		}
+	}
+}
+
+// shortWriter accepts at most max bytes per Write without reporting an error
+type shortWriter struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		p = p[:w.max]
+	}
+	return w.buf.Write(p)
+}
+
+func TestWriteFullRetriesShortWrites(t *testing.T) {
+	data := []byte(`{"timestamp":"2023-07-14T09:30:00Z","results":[]}` + "\n")
+	w := &shortWriter{max: 3}
+
+	n, err := writeFull(w, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(data) || !bytes.Equal(w.buf.Bytes(), data) {
+		t.Fatalf("wrote %d bytes %q, want %q", n, w.buf.Bytes(), data)
+	}
+}
+
+func TestWriteFullReportsStalledWriter(t *testing.T) {
+	n, err := writeFull(&shortWriter{max: 0}, []byte("entry\n"))
+	if n != 0 || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("got %d, %v; want 0, io.ErrShortWrite", n, err)
+	}
+}
+
+// breakFile closes the storage's held log file so writes to it fail, and
+// returns a func that reopens it
+func breakFile(t *testing.T, fs *FileStorage) func() {
+	t.Helper()
+	fs.file.Close()
+	return func() {
+		file, err := openLog(fs.filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs.file = file
+	}
+}
+
+// readLines returns the log file's lines, failing on any that isn't a
+// complete entry
+func readLines(t *testing.T, path string) []LogEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []LogEntry
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("partial line %q in log: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestFailedWriteIsRetriedWhole(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir(), WriteHeldOpen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	repair := breakFile(t, fs)
+	if err := fs.Save([]monitor.PingResult{{Host: "first.example", Success: true}}); err == nil {
+		t.Fatal("Save to a closed file succeeded")
+	}
+	repair()
+	if err := fs.Save([]monitor.PingResult{{Host: "second.example", Success: true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := readLines(t, fs.filePath)
+	if len(entries) != 2 || entries[0].Results[0].Host != "first.example" || entries[1].Results[0].Host != "second.example" {
+		t.Fatalf("got %+v, want the failed entry followed by the next one", entries)
+	}
+}
+
+func TestSaveDropsEntriesWhenBufferFull(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir(), WriteHeldOpen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	fs.maxBuffer = 300
+
+	repair := breakFile(t, fs)
+	results := []monitor.PingResult{{Host: "example.com", Success: true}}
+	for i := 0; i < 20; i++ {
+		fs.Save(results)
+		if len(fs.buffer) > fs.maxBuffer {
+			t.Fatalf("buffer grew to %d bytes, cap is %d", len(fs.buffer), fs.maxBuffer)
+		}
+	}
+	if err := fs.Save(results); err == nil || !strings.Contains(err.Error(), "dropping entry") {
+		t.Fatalf("Save with a full buffer returned %v, want a dropped entry", err)
+	}
+
+	repair()
+	if err := fs.Save(results); err != nil {
+		t.Fatal(err)
+	}
+	entries := readLines(t, fs.filePath)
+	if len(entries) < 2 || len(entries) > 20 {
+		t.Fatalf("got %d entries, want the buffered ones plus the last", len(entries))
+	}
+}
+
+func TestNewFileStorageWithClockPicksClockDay(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2023, 7, 14, 9, 30, 0, 0, time.UTC)
+	fs, err := NewFileStorageWithClock(dir, WriteHeldOpen, clock.NewFakeClock(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	if err := fs.Save([]monitor.PingResult{{Host: "example.com", Success: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "network_monitor_2023-07-14.jsonl")); err != nil {
+		t.Fatalf("log file for the clock's day missing: %v", err)
+	}
+
+	logs, err := fs.ReadLogs(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || !logs[0].Timestamp.Equal(now) {
+		t.Fatalf("got %+v, want one entry stamped %v", logs, now)
+	}
+}
+
+func TestSaveRotatesAtMidnight(t *testing.T) {
+	for _, mode := range []WriteMode{WriteHeldOpen, WriteReopen} {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := t.TempDir()
+			fake := clock.NewFakeClock(time.Date(2023, 7, 14, 23, 59, 30, 0, time.Local))
+			fs, err := NewFileStorageWithClock(dir, mode, fake)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fs.Close()
+
+			results := []monitor.PingResult{{Host: "example.com", Success: true}}
+			if err := fs.Save(results); err != nil {
+				t.Fatal(err)
+			}
+			fake.Advance(time.Minute)
+			if err := fs.Save(results); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, day := range []string{"2023-07-14", "2023-07-15"} {
+				data, err := os.ReadFile(filepath.Join(dir, "network_monitor_"+day+".jsonl"))
+				if err != nil {
+					t.Fatalf("log file for %s missing: %v", day, err)
+				}
+				if lines := bytes.Count(data, []byte("\n")); lines != 1 {
+					t.Errorf("%s has %d entries, want 1", day, lines)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSave compares the write modes: held keeps the file open, while
+// reopen opens, appends, fsyncs and closes it on every entry
+func BenchmarkSave(b *testing.B) {
+	results := make([]monitor.PingResult, 5)
+	for i := range results {
+		results[i] = monitor.PingResult{Host: fmt.Sprintf("host%d.example", i), Success: true, Latency: 25, Port: "443"}
+	}
+	for _, mode := range []WriteMode{WriteHeldOpen, WriteReopen} {
+		b.Run(string(mode), func(b *testing.B) {
+			fs, err := NewFileStorage(b.TempDir(), mode)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer fs.Close()
+
+			for b.Loop() {
+				if err := fs.Save(results); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
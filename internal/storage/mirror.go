@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// Sink receives each cycle's ping results
+type Sink interface {
+	Save(results []monitor.PingResult) error
+	Close() error
+}
+
+// HTTPMirror forwards results to another Monitrix instance's ingest endpoint
+type HTTPMirror struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewMirror creates a mirror sink for the given target. HTTP(S) URLs are
+// treated as a remote ingest endpoint; anything else is a data directory.
+func NewMirror(target, token string) (Sink, error) {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return NewHTTPMirror(target, token), nil
+	}
+	return NewFileStorage(target, WriteHeldOpen)
+}
+
+// NewHTTPMirror creates a mirror posting to the given ingest URL
+func NewHTTPMirror(url, token string) *HTTPMirror {
+	return &HTTPMirror{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Save posts the results to the remote ingest endpoint
+func (m *HTTPMirror) Save(results []monitor.PingResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, m.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create mirror request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to mirror results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("mirror endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Close is a no-op for HTTP mirrors
+func (m *HTTPMirror) Close() error {
+	return nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Reader reads log entries over an optional time range, oldest first
+type Reader interface {
+	ReadLogs(startTime, endTime *time.Time) ([]LogEntry, error)
+}
+
+// RemoteStorage reads logs from a peer Monitrix instance over its API
+type RemoteStorage struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewRemoteStorage creates a reader for the peer at baseURL, e.g. "http://nas:8080"
+func NewRemoteStorage(baseURL, token string) *RemoteStorage {
+	return &RemoteStorage{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// String returns the peer's base URL
+func (rs *RemoteStorage) String() string {
+	return rs.baseURL
+}
+
+// ReadLogs streams the peer's /api/logs.jsonl over the given range
+func (rs *RemoteStorage) ReadLogs(startTime, endTime *time.Time) ([]LogEntry, error) {
+	query := url.Values{}
+	if startTime != nil {
+		query.Set("start", startTime.Format(time.RFC3339))
+	}
+	if endTime != nil {
+		query.Set("end", endTime.Format(time.RFC3339))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rs.baseURL+"/api/logs.jsonl?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer request: %w", err)
+	}
+	if rs.token != "" {
+		req.Header.Set("Authorization", "Bearer "+rs.token)
+	}
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach peer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("peer responded with status %d", resp.StatusCode)
+	}
+
+	var entries []LogEntry
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var entry LogEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("failed to decode peer logs: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// logFileDate returns the day a log file covers from its name, e.g.
+// network_monitor_2024-01-31.jsonl or its compressed .jsonl.gz, or false
+// for anything else
+func logFileDate(name string) (time.Time, bool) {
+	date, ok := strings.CutPrefix(name, "network_monitor_")
+	if !ok {
+		return time.Time{}, false
+	}
+	date, ok = strings.CutSuffix(strings.TrimSuffix(date, ".gz"), ".jsonl")
+	if !ok {
+		return time.Time{}, false
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// PruneOlderThan deletes the daily log files in dataDir whose date is before
+// the day maxAge ago, returning how many were removed. The date comes from
+// the file name, not its modification time, and files that don't match the
+// log file pattern are left alone.
+func PruneOlderThan(dataDir string, maxAge time.Duration) (int, error) {
+	if maxAge <= 0 {
+		return 0, fmt.Errorf("retention must be positive, got %v", maxAge)
+	}
+	cutoffDay := retentionCutoff(maxAge)
+
+	files, err := filepath.Glob(filepath.Join(dataDir, "network_monitor_*.jsonl*"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	removed := 0
+	for _, filePath := range files {
+		day, ok := logFileDate(filepath.Base(filePath))
+		if !ok || !day.Before(cutoffDay) {
+			continue
+		}
+		if err := os.Remove(filePath); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", filePath, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// retentionCutoff returns the start of the day maxAge ago; log files dated
+// before it are past retention
+func retentionCutoff(maxAge time.Duration) time.Time {
+	cutoff := time.Now().Add(-maxAge)
+	return time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// sqliteDriver is the database/sql driver SQLiteStorage opens, registered
+// by modernc.org/sqlite in builds with the sqlite tag
+const sqliteDriver = "sqlite"
+
+// sqliteSchema creates the tables on first use. Each entry is a row in
+// entries and each of its results a row in results, so time-range and
+// per-host queries go through the indexes instead of reading everything.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id        INTEGER PRIMARY KEY,
+	timestamp INTEGER NOT NULL,
+	aggregate TEXT
+);
+CREATE INDEX IF NOT EXISTS entries_timestamp ON entries (timestamp);
+CREATE TABLE IF NOT EXISTS results (
+	entry_id  INTEGER NOT NULL REFERENCES entries (id),
+	timestamp INTEGER NOT NULL,
+	host      TEXT NOT NULL,
+	result    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_entry ON results (entry_id);
+CREATE INDEX IF NOT EXISTS results_timestamp ON results (timestamp);
+CREATE INDEX IF NOT EXISTS results_host ON results (host, timestamp);
+`
+
+// SQLiteStorage stores log entries in a SQLite database, an alternative to
+// FileStorage for long histories
+type SQLiteStorage struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+// NewSQLiteStorage opens the database at path, creating it and its tables if needed
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// SQLite allows one writer at a time; a single connection serializes
+	// access instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", sqliteSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to prepare database: %w", err)
+		}
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// String returns a description of the storage for log messages
+func (ss *SQLiteStorage) String() string {
+	return "sqlite"
+}
+
+// Save writes ping results as one log entry
+func (ss *SQLiteStorage) Save(results []monitor.PingResult) error {
+	return ss.SaveEntry(LogEntry{
+		Timestamp: time.Now(),
+		Results:   results,
+	})
+}
+
+// SaveEntry writes a complete log entry in a single transaction
+func (ss *SQLiteStorage) SaveEntry(entry LogEntry) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var aggregate sql.NullString
+	if entry.Aggregate != nil {
+		data, err := json.Marshal(entry.Aggregate)
+		if err != nil {
+			return fmt.Errorf("failed to marshal log entry: %w", err)
+		}
+		aggregate = sql.NullString{String: string(data), Valid: true}
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO entries (timestamp, aggregate) VALUES (?, ?)`, entry.Timestamp.UnixNano(), aggregate)
+	if err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	for _, result := range entry.Results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal log entry: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO results (entry_id, timestamp, host, result) VALUES (?, ?, ?, ?)`,
+			id, entry.Timestamp.UnixNano(), result.Host, string(data)); err != nil {
+			return fmt.Errorf("failed to write log entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	return nil
+}
+
+// ReadLogs reads the log entries in the time range, oldest first
+func (ss *SQLiteStorage) ReadLogs(startTime, endTime *time.Time) ([]LogEntry, error) {
+	var entries []LogEntry
+	err := ss.ScanLogs(startTime, endTime, func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+	return entries, err
+}
+
+// ScanLogs calls fn for each log entry in the time range, oldest first
+func (ss *SQLiteStorage) ScanLogs(startTime, endTime *time.Time, fn func(LogEntry)) error {
+	var where []string
+	var args []any
+	if startTime != nil {
+		where = append(where, "e.timestamp >= ?")
+		args = append(args, startTime.UnixNano())
+	}
+	if endTime != nil {
+		where = append(where, "e.timestamp <= ?")
+		args = append(args, endTime.UnixNano())
+	}
+	query := `SELECT e.id, e.timestamp, e.aggregate, r.result
+		FROM entries e LEFT JOIN results r ON r.entry_id = e.id`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY e.timestamp, e.id, r.rowid"
+
+	rows, err := ss.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	// Rows arrive grouped by entry; each entry is passed on once its last
+	// result has been read
+	var current *LogEntry
+	var currentID int64
+	for rows.Next() {
+		var id, timestamp int64
+		var aggregate, result sql.NullString
+		if err := rows.Scan(&id, &timestamp, &aggregate, &result); err != nil {
+			return fmt.Errorf("failed to read logs: %w", err)
+		}
+
+		if current == nil || id != currentID {
+			if current != nil {
+				fn(*current)
+			}
+			current = &LogEntry{Timestamp: time.Unix(0, timestamp), Results: []monitor.PingResult{}}
+			currentID = id
+			if aggregate.Valid {
+				current.Aggregate = &EntryAggregate{}
+				if err := json.Unmarshal([]byte(aggregate.String), current.Aggregate); err != nil {
+					current.Aggregate = nil
+				}
+			}
+		}
+		if result.Valid {
+			var pr monitor.PingResult
+			if err := json.Unmarshal([]byte(result.String), &pr); err != nil {
+				continue
+			}
+			current.Results = append(current.Results, pr)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+	if current != nil {
+		fn(*current)
+	}
+	return nil
+}
+
+// Close closes the database
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
@@ -0,0 +1,4 @@
+package storage
+
+// Registers the pure-Go SQLite driver used by SQLiteStorage
+import _ "modernc.org/sqlite"
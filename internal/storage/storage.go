@@ -0,0 +1,15 @@
+package storage
+
+import "time"
+
+// Storage is a backend that stores each cycle's results and reads them back
+type Storage interface {
+	Sink
+	Reader
+}
+
+// Scanner is implemented by backends that can stream a time range entry by
+// entry, oldest first, instead of loading it all
+type Scanner interface {
+	ScanLogs(startTime, endTime *time.Time, fn func(LogEntry)) error
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// summariesFile keeps a roll-up entry for every daily log file summarized
+// before retention pruned it
+const summariesFile = "summaries.jsonl"
+
+// SummarizeDay rolls a day's entries, oldest first, into one summary entry
+// like those written by an Aggregator, adding how long the internet was
+// down by criteria. interval is the longest probe interval of any host
+// (see monitor.LatestResults). It returns false for a day without entries.
+func SummarizeDay(entries []LogEntry, criteria monitor.UpCriteria, interval time.Duration) (LogEntry, bool) {
+	if len(entries) == 0 {
+		return LogEntry{}, false
+	}
+
+	rollup := NewAggregator(nil, math.MaxInt, criteria, interval)
+	latest := monitor.LatestResults{Interval: interval}
+	var downtime time.Duration
+	var downSince *time.Time
+	for _, entry := range entries {
+		down := false
+		if entry.Aggregate != nil {
+			rollup.addRollup(entry)
+			down = entry.Aggregate.OnlineCycles == 0 && entry.Aggregate.Cycles > entry.Aggregate.PausedCycles
+			downtime += time.Duration(entry.Aggregate.DowntimeSeconds) * time.Second
+		} else {
+			rollup.save(entry.Results, entry.Timestamp)
+			paused := len(entry.Results) > 0
+			for _, result := range entry.Results {
+				paused = paused && result.Paused
+			}
+			online, _ := monitor.IsInternetUp(latest.Update(entry.Results), criteria)
+			down = !paused && !online
+		}
+
+		// Downtime runs from the first offline entry to the next one that isn't
+		if downSince != nil && !down {
+			downtime += entry.Timestamp.Sub(*downSince)
+			downSince = nil
+		} else if downSince == nil && down {
+			downSince = &entry.Timestamp
+		}
+	}
+	last := entries[len(entries)-1].Timestamp
+	if downSince != nil {
+		downtime += last.Sub(*downSince)
+	}
+
+	summary := rollup.take(last)
+	summary.Aggregate.DowntimeSeconds = int64(downtime.Seconds())
+	return summary, true
+}
+
+// SummarizeOlderThan adds a daily summary to summaries.jsonl in dataDir for
+// every log file PruneOlderThan would remove that hasn't been summarized
+// yet, returning how many were added. Run it before pruning, so long-term
+// trends outlive the raw logs.
+func SummarizeOlderThan(dataDir string, maxAge time.Duration, criteria monitor.UpCriteria, interval time.Duration) (int, error) {
+	if maxAge <= 0 {
+		return 0, fmt.Errorf("retention must be positive, got %v", maxAge)
+	}
+	cutoff := retentionCutoff(maxAge)
+
+	summarized := make(map[string]bool)
+	if err := scanSummaries(dataDir, func(entry LogEntry) {
+		summarized[summaryDay(entry)] = true
+	}); err != nil {
+		return 0, err
+	}
+
+	files, err := logFiles(dataDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list log files: %w", err)
+	}
+	var summaries []LogEntry
+	for _, filePath := range files {
+		day, ok := logFileDate(filepath.Base(filePath))
+		if !ok || !day.Before(cutoff) || summarized[day.Format("2006-01-02")] {
+			continue
+		}
+		entries, err := readLogFile(filePath)
+		if err != nil {
+			return 0, err
+		}
+		if summary, ok := SummarizeDay(entries, criteria, interval); ok {
+			summaries = append(summaries, summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return 0, nil
+	}
+
+	file, err := os.OpenFile(filepath.Join(dataDir, summariesFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open summaries: %w", err)
+	}
+	defer file.Close()
+	var data []byte
+	for _, summary := range summaries {
+		line, err := json.Marshal(summary)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal summary: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if _, err := writeFull(file, data); err != nil {
+		return 0, fmt.Errorf("failed to write summaries: %w", err)
+	}
+	return len(summaries), file.Sync()
+}
+
+// readLogFile reads one log file's entries in timestamp order
+func readLogFile(filePath string) ([]LogEntry, error) {
+	file, err := openLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	var entries []LogEntry
+	if _, err := scanFile(file, nil, nil, func(entry LogEntry) {
+		entries = append(entries, entry)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// summaryDay returns the date, in the log files' YYYY-MM-DD form, of the
+// day a summary covers
+func summaryDay(entry LogEntry) string {
+	start := entry.Timestamp
+	if entry.Aggregate != nil {
+		start = entry.Aggregate.Start
+	}
+	return start.In(time.Local).Format("2006-01-02")
+}
+
+// scanSummaries calls fn for each daily summary in dataDir
+func scanSummaries(dataDir string, fn func(LogEntry)) error {
+	filePath := filepath.Join(dataDir, summariesFile)
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read summaries: %w", err)
+	}
+	defer file.Close()
+
+	skipped, err := scanFile(file, nil, nil, fn)
+	reportCorrupt(filePath, skipped)
+	return err
+}
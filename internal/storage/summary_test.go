@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"monitrix/internal/monitor"
+)
+
+// writeDay writes entries to the log file of day, as FileStorage would
+func writeDay(t *testing.T, dir string, day time.Time, entries []LogEntry) {
+	t.Helper()
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	name := "network_monitor_" + day.Format("2006-01-02") + ".jsonl"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSummarizeBeforePruning(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Date(2020, 5, 4, 12, 0, 0, 0, time.Local)
+	recent := time.Now().Add(-time.Hour)
+	probe := func(at time.Time, success bool) LogEntry {
+		result := monitor.PingResult{Host: "a", Timestamp: at, Success: success, Latency: 20}
+		if !success {
+			result.Error, result.Latency = "i/o timeout", 0
+		}
+		return LogEntry{Timestamp: at, Results: []monitor.PingResult{result}}
+	}
+
+	// Down from 12:01 until 12:04
+	writeDay(t, dir, old, []LogEntry{
+		probe(old, true),
+		probe(old.Add(time.Minute), false),
+		probe(old.Add(3*time.Minute), false),
+		probe(old.Add(4*time.Minute), true),
+	})
+	writeDay(t, dir, recent, []LogEntry{probe(recent, true)})
+
+	retention := 30 * 24 * time.Hour
+	for run, want := range []int{1, 0} {
+		added, err := SummarizeOlderThan(dir, retention, monitor.UpCriteria{}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != want {
+			t.Errorf("run %d: summarized %d day(s), want %d", run+1, added, want)
+		}
+	}
+	if removed, err := PruneOlderThan(dir, retention); err != nil || removed != 1 {
+		t.Fatalf("pruned %d file(s), err %v; want the old day's", removed, err)
+	}
+
+	logs, err := ReadLogs(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 || logs[0].Aggregate == nil || logs[1].Aggregate != nil {
+		t.Fatalf("got %+v, want the old day's summary then the recent raw entry", logs)
+	}
+
+	summary := logs[0]
+	if agg := summary.Aggregate; agg.Cycles != 4 || agg.OnlineCycles != 2 || agg.DowntimeSeconds != 180 {
+		t.Errorf("summary = %+v, want 4 cycles, 2 online and 180s down", agg)
+	}
+	if !summary.Aggregate.Start.Equal(old) || !summary.Timestamp.Equal(old.Add(4*time.Minute)) {
+		t.Errorf("summary spans %v to %v, want the day's first to last entry", summary.Aggregate.Start, summary.Timestamp)
+	}
+	host := summary.Results[0]
+	if host.Aggregate.Successes != 2 || host.Aggregate.LatencySamples != 2 || host.Latency != 20 {
+		t.Errorf("host summary = %+v (%+v), want 2 successes averaging 20ms", host, host.Aggregate)
+	}
+
+	// A range ending before the summarized day leaves it out
+	before := old.Add(-time.Hour)
+	if logs, _ := ReadLogs(dir, nil, &before); len(logs) != 0 {
+		t.Errorf("got %d entries before the summarized day, want none", len(logs))
+	}
+}